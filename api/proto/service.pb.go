@@ -13,6 +13,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -25,6 +26,9 @@ const (
 type URLShortenRequest struct {
 	state                  protoimpl.MessageState `protogen:"opaque.v1"`
 	xxx_hidden_Url         *string                `protobuf:"bytes,1,opt,name=url"`
+	xxx_hidden_Alias       *string                `protobuf:"bytes,2,opt,name=alias"`
+	xxx_hidden_ExpiresAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt"`
+	xxx_hidden_TtlSeconds  int64                  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds"`
 	XXX_raceDetectHookData protoimpl.RaceDetectHookData
 	XXX_presence           [1]uint32
 	unknownFields          protoimpl.UnknownFields
@@ -66,9 +70,47 @@ func (x *URLShortenRequest) GetUrl() string {
 	return ""
 }
 
+func (x *URLShortenRequest) GetAlias() string {
+	if x != nil {
+		if x.xxx_hidden_Alias != nil {
+			return *x.xxx_hidden_Alias
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *URLShortenRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_ExpiresAt
+	}
+	return nil
+}
+
+func (x *URLShortenRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.xxx_hidden_TtlSeconds
+	}
+	return 0
+}
+
 func (x *URLShortenRequest) SetUrl(v string) {
 	x.xxx_hidden_Url = &v
-	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *URLShortenRequest) SetAlias(v string) {
+	x.xxx_hidden_Alias = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *URLShortenRequest) SetExpiresAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_ExpiresAt = v
+}
+
+func (x *URLShortenRequest) SetTtlSeconds(v int64) {
+	x.xxx_hidden_TtlSeconds = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
 }
 
 func (x *URLShortenRequest) HasUrl() bool {
@@ -78,15 +120,53 @@ func (x *URLShortenRequest) HasUrl() bool {
 	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
 }
 
+func (x *URLShortenRequest) HasAlias() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *URLShortenRequest) HasExpiresAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_ExpiresAt != nil
+}
+
+func (x *URLShortenRequest) HasTtlSeconds() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
 func (x *URLShortenRequest) ClearUrl() {
 	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
 	x.xxx_hidden_Url = nil
 }
 
+func (x *URLShortenRequest) ClearAlias() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Alias = nil
+}
+
+func (x *URLShortenRequest) ClearExpiresAt() {
+	x.xxx_hidden_ExpiresAt = nil
+}
+
+func (x *URLShortenRequest) ClearTtlSeconds() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_TtlSeconds = 0
+}
+
 type URLShortenRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
-	Url *string
+	Url        *string
+	Alias      *string
+	ExpiresAt  *timestamppb.Timestamp
+	TtlSeconds *int64
 }
 
 func (b0 URLShortenRequest_builder) Build() *URLShortenRequest {
@@ -94,9 +174,18 @@ func (b0 URLShortenRequest_builder) Build() *URLShortenRequest {
 	b, x := &b0, m0
 	_, _ = b, x
 	if b.Url != nil {
-		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
 		x.xxx_hidden_Url = b.Url
 	}
+	if b.Alias != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_Alias = b.Alias
+	}
+	x.xxx_hidden_ExpiresAt = b.ExpiresAt
+	if b.TtlSeconds != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_TtlSeconds = *b.TtlSeconds
+	}
 	return m0
 }
 
@@ -504,53 +593,1219 @@ func (b0 URLData_builder) Build() *URLData {
 	return m0
 }
 
-var File_proto_service_proto protoreflect.FileDescriptor
+type IssueTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"opaque.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_proto_service_proto_rawDesc = "" +
-	"\n" +
-	"\x13proto/service.proto\x12\rurl.shortener\x1a\x1bgoogle/protobuf/empty.proto\"%\n" +
-	"\x11URLShortenRequest\x12\x10\n" +
-	"\x03url\x18\x01 \x01(\tR\x03url\",\n" +
-	"\x12URLShortenResponse\x12\x16\n" +
-	"\x06result\x18\x01 \x01(\tR\x06result\"\"\n" +
-	"\x10URLExpandRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"+\n" +
-	"\x11URLExpandResponse\x12\x16\n" +
-	"\x06result\x18\x01 \x01(\tR\x06result\"<\n" +
-	"\x10UserURLsResponse\x12(\n" +
-	"\x03url\x18\x01 \x03(\v2\x16.url.shortener.URLDataR\x03url\"I\n" +
-	"\aURLData\x12\x1b\n" +
-	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
-	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl2\xfe\x01\n" +
-	"\x10ShortenerService\x12Q\n" +
-	"\n" +
-	"ShortenURL\x12 .url.shortener.URLShortenRequest\x1a!.url.shortener.URLShortenResponse\x12N\n" +
-	"\tExpandURL\x12\x1f.url.shortener.URLExpandRequest\x1a .url.shortener.URLExpandResponse\x12G\n" +
-	"\fListUserURLs\x12\x16.google.protobuf.Empty\x1a\x1f.url.shortener.UserURLsResponseB/Z-github.com/noedaka/go-url-shortener/api/protob\beditionsp\xe8\a"
+func (x *IssueTokenRequest) Reset() {
+	*x = IssueTokenRequest{}
+	mi := &file_proto_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueTokenRequest) ProtoMessage() {}
+
+func (x *IssueTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+type IssueTokenRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+}
+
+func (b0 IssueTokenRequest_builder) Build() *IssueTokenRequest {
+	m0 := &IssueTokenRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	return m0
+}
+
+type RefreshTokenRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Token       *string                `protobuf:"bytes,1,opt,name=token"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *RefreshTokenRequest) Reset() {
+	*x = RefreshTokenRequest{}
+	mi := &file_proto_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenRequest) ProtoMessage() {}
+
+func (x *RefreshTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *RefreshTokenRequest) GetToken() string {
+	if x != nil {
+		if x.xxx_hidden_Token != nil {
+			return *x.xxx_hidden_Token
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *RefreshTokenRequest) SetToken(v string) {
+	x.xxx_hidden_Token = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 1)
+}
+
+func (x *RefreshTokenRequest) HasToken() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *RefreshTokenRequest) ClearToken() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Token = nil
+}
+
+type RefreshTokenRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Token *string
+}
+
+func (b0 RefreshTokenRequest_builder) Build() *RefreshTokenRequest {
+	m0 := &RefreshTokenRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Token != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 1)
+		x.xxx_hidden_Token = b.Token
+	}
+	return m0
+}
+
+type TokenResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Token       *string                `protobuf:"bytes,1,opt,name=token"`
+	xxx_hidden_UserId      *string                `protobuf:"bytes,2,opt,name=user_id,json=userId"`
+	xxx_hidden_ExpiresAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *TokenResponse) Reset() {
+	*x = TokenResponse{}
+	mi := &file_proto_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenResponse) ProtoMessage() {}
+
+func (x *TokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *TokenResponse) GetToken() string {
+	if x != nil {
+		if x.xxx_hidden_Token != nil {
+			return *x.xxx_hidden_Token
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TokenResponse) GetUserId() string {
+	if x != nil {
+		if x.xxx_hidden_UserId != nil {
+			return *x.xxx_hidden_UserId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *TokenResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_ExpiresAt
+	}
+	return nil
+}
+
+func (x *TokenResponse) SetToken(v string) {
+	x.xxx_hidden_Token = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 3)
+}
+
+func (x *TokenResponse) SetUserId(v string) {
+	x.xxx_hidden_UserId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 3)
+}
+
+func (x *TokenResponse) SetExpiresAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_ExpiresAt = v
+}
+
+func (x *TokenResponse) HasToken() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *TokenResponse) HasUserId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *TokenResponse) HasExpiresAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_ExpiresAt != nil
+}
+
+func (x *TokenResponse) ClearToken() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Token = nil
+}
+
+func (x *TokenResponse) ClearUserId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_UserId = nil
+}
+
+func (x *TokenResponse) ClearExpiresAt() {
+	x.xxx_hidden_ExpiresAt = nil
+}
 
-var file_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+type TokenResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Token     *string
+	UserId    *string
+	ExpiresAt *timestamppb.Timestamp
+}
+
+func (b0 TokenResponse_builder) Build() *TokenResponse {
+	m0 := &TokenResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Token != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 3)
+		x.xxx_hidden_Token = b.Token
+	}
+	if b.UserId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 3)
+		x.xxx_hidden_UserId = b.UserId
+	}
+	x.xxx_hidden_ExpiresAt = b.ExpiresAt
+	return m0
+}
+
+type BatchURL struct {
+	state                    protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_CorrelationId *string                `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId"`
+	xxx_hidden_OriginalUrl   *string                `protobuf:"bytes,2,opt,name=original_url,json=originalUrl"`
+	xxx_hidden_Alias         *string                `protobuf:"bytes,3,opt,name=alias"`
+	xxx_hidden_ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt"`
+	xxx_hidden_TtlSeconds    int64                  `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds"`
+	XXX_raceDetectHookData   protoimpl.RaceDetectHookData
+	XXX_presence             [1]uint32
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *BatchURL) Reset() {
+	*x = BatchURL{}
+	mi := &file_proto_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchURL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchURL) ProtoMessage() {}
+
+func (x *BatchURL) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *BatchURL) GetCorrelationId() string {
+	if x != nil {
+		if x.xxx_hidden_CorrelationId != nil {
+			return *x.xxx_hidden_CorrelationId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BatchURL) GetOriginalUrl() string {
+	if x != nil {
+		if x.xxx_hidden_OriginalUrl != nil {
+			return *x.xxx_hidden_OriginalUrl
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BatchURL) GetAlias() string {
+	if x != nil {
+		if x.xxx_hidden_Alias != nil {
+			return *x.xxx_hidden_Alias
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BatchURL) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_ExpiresAt
+	}
+	return nil
+}
+
+func (x *BatchURL) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.xxx_hidden_TtlSeconds
+	}
+	return 0
+}
+
+func (x *BatchURL) SetCorrelationId(v string) {
+	x.xxx_hidden_CorrelationId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 5)
+}
+
+func (x *BatchURL) SetOriginalUrl(v string) {
+	x.xxx_hidden_OriginalUrl = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 5)
+}
+
+func (x *BatchURL) SetAlias(v string) {
+	x.xxx_hidden_Alias = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 5)
+}
+
+func (x *BatchURL) SetExpiresAt(v *timestamppb.Timestamp) {
+	x.xxx_hidden_ExpiresAt = v
+}
+
+func (x *BatchURL) SetTtlSeconds(v int64) {
+	x.xxx_hidden_TtlSeconds = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 4, 5)
+}
+
+func (x *BatchURL) HasCorrelationId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *BatchURL) HasOriginalUrl() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *BatchURL) HasAlias() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *BatchURL) HasExpiresAt() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_ExpiresAt != nil
+}
+
+func (x *BatchURL) HasTtlSeconds() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 4)
+}
+
+func (x *BatchURL) ClearCorrelationId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_CorrelationId = nil
+}
+
+func (x *BatchURL) ClearOriginalUrl() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_OriginalUrl = nil
+}
+
+func (x *BatchURL) ClearAlias() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Alias = nil
+}
+
+func (x *BatchURL) ClearExpiresAt() {
+	x.xxx_hidden_ExpiresAt = nil
+}
+
+func (x *BatchURL) ClearTtlSeconds() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 4)
+	x.xxx_hidden_TtlSeconds = 0
+}
+
+type BatchURL_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	CorrelationId *string
+	OriginalUrl   *string
+	Alias         *string
+	ExpiresAt     *timestamppb.Timestamp
+	TtlSeconds    *int64
+}
+
+func (b0 BatchURL_builder) Build() *BatchURL {
+	m0 := &BatchURL{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.CorrelationId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 5)
+		x.xxx_hidden_CorrelationId = b.CorrelationId
+	}
+	if b.OriginalUrl != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 5)
+		x.xxx_hidden_OriginalUrl = b.OriginalUrl
+	}
+	if b.Alias != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 5)
+		x.xxx_hidden_Alias = b.Alias
+	}
+	x.xxx_hidden_ExpiresAt = b.ExpiresAt
+	if b.TtlSeconds != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 4, 5)
+		x.xxx_hidden_TtlSeconds = *b.TtlSeconds
+	}
+	return m0
+}
+
+type ShortenBatchRequest struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Urls *[]*BatchURL           `protobuf:"bytes,1,rep,name=urls"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ShortenBatchRequest) Reset() {
+	*x = ShortenBatchRequest{}
+	mi := &file_proto_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenBatchRequest) ProtoMessage() {}
+
+func (x *ShortenBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ShortenBatchRequest) GetUrls() []*BatchURL {
+	if x != nil {
+		if x.xxx_hidden_Urls != nil {
+			return *x.xxx_hidden_Urls
+		}
+	}
+	return nil
+}
+
+func (x *ShortenBatchRequest) SetUrls(v []*BatchURL) {
+	x.xxx_hidden_Urls = &v
+}
+
+type ShortenBatchRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Urls []*BatchURL
+}
+
+func (b0 ShortenBatchRequest_builder) Build() *ShortenBatchRequest {
+	m0 := &ShortenBatchRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Urls = &b.Urls
+	return m0
+}
+
+type BatchShortURL struct {
+	state                    protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_CorrelationId *string                `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId"`
+	xxx_hidden_ShortUrl      *string                `protobuf:"bytes,2,opt,name=short_url,json=shortUrl"`
+	XXX_raceDetectHookData   protoimpl.RaceDetectHookData
+	XXX_presence             [1]uint32
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *BatchShortURL) Reset() {
+	*x = BatchShortURL{}
+	mi := &file_proto_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchShortURL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchShortURL) ProtoMessage() {}
+
+func (x *BatchShortURL) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *BatchShortURL) GetCorrelationId() string {
+	if x != nil {
+		if x.xxx_hidden_CorrelationId != nil {
+			return *x.xxx_hidden_CorrelationId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BatchShortURL) GetShortUrl() string {
+	if x != nil {
+		if x.xxx_hidden_ShortUrl != nil {
+			return *x.xxx_hidden_ShortUrl
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *BatchShortURL) SetCorrelationId(v string) {
+	x.xxx_hidden_CorrelationId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *BatchShortURL) SetShortUrl(v string) {
+	x.xxx_hidden_ShortUrl = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *BatchShortURL) HasCorrelationId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *BatchShortURL) HasShortUrl() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *BatchShortURL) ClearCorrelationId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_CorrelationId = nil
+}
+
+func (x *BatchShortURL) ClearShortUrl() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_ShortUrl = nil
+}
+
+type BatchShortURL_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	CorrelationId *string
+	ShortUrl      *string
+}
+
+func (b0 BatchShortURL_builder) Build() *BatchShortURL {
+	m0 := &BatchShortURL{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.CorrelationId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_CorrelationId = b.CorrelationId
+	}
+	if b.ShortUrl != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_ShortUrl = b.ShortUrl
+	}
+	return m0
+}
+
+type ShortenBatchResponse struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Urls *[]*BatchShortURL      `protobuf:"bytes,1,rep,name=urls"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ShortenBatchResponse) Reset() {
+	*x = ShortenBatchResponse{}
+	mi := &file_proto_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenBatchResponse) ProtoMessage() {}
+
+func (x *ShortenBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ShortenBatchResponse) GetUrls() []*BatchShortURL {
+	if x != nil {
+		if x.xxx_hidden_Urls != nil {
+			return *x.xxx_hidden_Urls
+		}
+	}
+	return nil
+}
+
+func (x *ShortenBatchResponse) SetUrls(v []*BatchShortURL) {
+	x.xxx_hidden_Urls = &v
+}
+
+type ShortenBatchResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Urls []*BatchShortURL
+}
+
+func (b0 ShortenBatchResponse_builder) Build() *ShortenBatchResponse {
+	m0 := &ShortenBatchResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Urls = &b.Urls
+	return m0
+}
+
+type DeleteUserURLsRequest struct {
+	state                protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_ShortUrls []string               `protobuf:"bytes,1,rep,name=short_urls,json=shortUrls"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *DeleteUserURLsRequest) Reset() {
+	*x = DeleteUserURLsRequest{}
+	mi := &file_proto_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserURLsRequest) ProtoMessage() {}
+
+func (x *DeleteUserURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DeleteUserURLsRequest) GetShortUrls() []string {
+	if x != nil {
+		return x.xxx_hidden_ShortUrls
+	}
+	return nil
+}
+
+func (x *DeleteUserURLsRequest) SetShortUrls(v []string) {
+	x.xxx_hidden_ShortUrls = v
+}
+
+type DeleteUserURLsRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	ShortUrls []string
+}
+
+func (b0 DeleteUserURLsRequest_builder) Build() *DeleteUserURLsRequest {
+	m0 := &DeleteUserURLsRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_ShortUrls = b.ShortUrls
+	return m0
+}
+
+type StatsResponse struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Urls        int64                  `protobuf:"varint,1,opt,name=urls"`
+	xxx_hidden_Users       int64                  `protobuf:"varint,2,opt,name=users"`
+	XXX_raceDetectHookData protoimpl.RaceDetectHookData
+	XXX_presence           [1]uint32
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_proto_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *StatsResponse) GetUrls() int64 {
+	if x != nil {
+		return x.xxx_hidden_Urls
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetUsers() int64 {
+	if x != nil {
+		return x.xxx_hidden_Users
+	}
+	return 0
+}
+
+func (x *StatsResponse) SetUrls(v int64) {
+	x.xxx_hidden_Urls = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 2)
+}
+
+func (x *StatsResponse) SetUsers(v int64) {
+	x.xxx_hidden_Users = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 2)
+}
+
+func (x *StatsResponse) HasUrls() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *StatsResponse) HasUsers() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *StatsResponse) ClearUrls() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_Urls = 0
+}
+
+func (x *StatsResponse) ClearUsers() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_Users = 0
+}
+
+type StatsResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Urls  *int64
+	Users *int64
+}
+
+func (b0 StatsResponse_builder) Build() *StatsResponse {
+	m0 := &StatsResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.Urls != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 2)
+		x.xxx_hidden_Urls = *b.Urls
+	}
+	if b.Users != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 2)
+		x.xxx_hidden_Users = *b.Users
+	}
+	return m0
+}
+
+type ShortenResult struct {
+	state                    protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_CorrelationId *string                `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId"`
+	xxx_hidden_ShortUrl      *string                `protobuf:"bytes,2,opt,name=short_url,json=shortUrl"`
+	xxx_hidden_Code          int32                  `protobuf:"varint,3,opt,name=code"`
+	xxx_hidden_Error         *string                `protobuf:"bytes,4,opt,name=error"`
+	XXX_raceDetectHookData   protoimpl.RaceDetectHookData
+	XXX_presence             [1]uint32
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *ShortenResult) Reset() {
+	*x = ShortenResult{}
+	mi := &file_proto_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenResult) ProtoMessage() {}
+
+func (x *ShortenResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ShortenResult) GetCorrelationId() string {
+	if x != nil {
+		if x.xxx_hidden_CorrelationId != nil {
+			return *x.xxx_hidden_CorrelationId
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ShortenResult) GetShortUrl() string {
+	if x != nil {
+		if x.xxx_hidden_ShortUrl != nil {
+			return *x.xxx_hidden_ShortUrl
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ShortenResult) GetCode() int32 {
+	if x != nil {
+		return x.xxx_hidden_Code
+	}
+	return 0
+}
+
+func (x *ShortenResult) GetError() string {
+	if x != nil {
+		if x.xxx_hidden_Error != nil {
+			return *x.xxx_hidden_Error
+		}
+		return ""
+	}
+	return ""
+}
+
+func (x *ShortenResult) SetCorrelationId(v string) {
+	x.xxx_hidden_CorrelationId = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 0, 4)
+}
+
+func (x *ShortenResult) SetShortUrl(v string) {
+	x.xxx_hidden_ShortUrl = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 1, 4)
+}
+
+func (x *ShortenResult) SetCode(v int32) {
+	x.xxx_hidden_Code = v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 2, 4)
+}
+
+func (x *ShortenResult) SetError(v string) {
+	x.xxx_hidden_Error = &v
+	protoimpl.X.SetPresent(&(x.XXX_presence[0]), 3, 4)
+}
+
+func (x *ShortenResult) HasCorrelationId() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 0)
+}
+
+func (x *ShortenResult) HasShortUrl() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 1)
+}
+
+func (x *ShortenResult) HasCode() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 2)
+}
+
+func (x *ShortenResult) HasError() bool {
+	if x == nil {
+		return false
+	}
+	return protoimpl.X.Present(&(x.XXX_presence[0]), 3)
+}
+
+func (x *ShortenResult) ClearCorrelationId() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 0)
+	x.xxx_hidden_CorrelationId = nil
+}
+
+func (x *ShortenResult) ClearShortUrl() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 1)
+	x.xxx_hidden_ShortUrl = nil
+}
+
+func (x *ShortenResult) ClearCode() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 2)
+	x.xxx_hidden_Code = 0
+}
+
+func (x *ShortenResult) ClearError() {
+	protoimpl.X.ClearPresent(&(x.XXX_presence[0]), 3)
+	x.xxx_hidden_Error = nil
+}
+
+type ShortenResult_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	CorrelationId *string
+	// short_url содержит сокращенный URL, а при конфликте - уже существующий.
+	ShortUrl *string
+	// code содержит код google.rpc.Code, 0 при успехе.
+	Code  *int32
+	Error *string
+}
+
+func (b0 ShortenResult_builder) Build() *ShortenResult {
+	m0 := &ShortenResult{}
+	b, x := &b0, m0
+	_, _ = b, x
+	if b.CorrelationId != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 0, 4)
+		x.xxx_hidden_CorrelationId = b.CorrelationId
+	}
+	if b.ShortUrl != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 1, 4)
+		x.xxx_hidden_ShortUrl = b.ShortUrl
+	}
+	if b.Code != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 2, 4)
+		x.xxx_hidden_Code = *b.Code
+	}
+	if b.Error != nil {
+		protoimpl.X.SetPresentNonAtomic(&(x.XXX_presence[0]), 3, 4)
+		x.xxx_hidden_Error = b.Error
+	}
+	return m0
+}
+
+type ShortenStreamResponse struct {
+	state              protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Results *[]*ShortenResult      `protobuf:"bytes,1,rep,name=results"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ShortenStreamResponse) Reset() {
+	*x = ShortenStreamResponse{}
+	mi := &file_proto_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShortenStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShortenStreamResponse) ProtoMessage() {}
+
+func (x *ShortenStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ShortenStreamResponse) GetResults() []*ShortenResult {
+	if x != nil {
+		if x.xxx_hidden_Results != nil {
+			return *x.xxx_hidden_Results
+		}
+	}
+	return nil
+}
+
+func (x *ShortenStreamResponse) SetResults(v []*ShortenResult) {
+	x.xxx_hidden_Results = &v
+}
+
+type ShortenStreamResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Results []*ShortenResult
+}
+
+func (b0 ShortenStreamResponse_builder) Build() *ShortenStreamResponse {
+	m0 := &ShortenStreamResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Results = &b.Results
+	return m0
+}
+
+var File_proto_service_proto protoreflect.FileDescriptor
+
+const file_proto_service_proto_rawDesc = "" +
+	"\n" +
+	"\x13proto/service.proto\x12\rurl.shortener\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x97\x01\n" +
+	"\x11URLShortenRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\",\n" +
+	"\x12URLShortenResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"\"\n" +
+	"\x10URLExpandRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"+\n" +
+	"\x11URLExpandResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\"<\n" +
+	"\x10UserURLsResponse\x12(\n" +
+	"\x03url\x18\x01 \x03(\v2\x16.url.shortener.URLDataR\x03url\"I\n" +
+	"\aURLData\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\"\x13\n" +
+	"\x11IssueTokenRequest\"+\n" +
+	"\x13RefreshTokenRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"y\n" +
+	"\rTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xc6\x01\n" +
+	"\bBatchURL\x12%\n" +
+	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x14\n" +
+	"\x05alias\x18\x03 \x01(\tR\x05alias\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x03R\n" +
+	"ttlSeconds\"B\n" +
+	"\x13ShortenBatchRequest\x12+\n" +
+	"\x04urls\x18\x01 \x03(\v2\x17.url.shortener.BatchURLR\x04urls\"S\n" +
+	"\rBatchShortURL\x12%\n" +
+	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12\x1b\n" +
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\"H\n" +
+	"\x14ShortenBatchResponse\x120\n" +
+	"\x04urls\x18\x01 \x03(\v2\x1c.url.shortener.BatchShortURLR\x04urls\"6\n" +
+	"\x15DeleteUserURLsRequest\x12\x1d\n" +
+	"\n" +
+	"short_urls\x18\x01 \x03(\tR\tshortUrls\"9\n" +
+	"\rStatsResponse\x12\x12\n" +
+	"\x04urls\x18\x01 \x01(\x03R\x04urls\x12\x14\n" +
+	"\x05users\x18\x02 \x01(\x03R\x05users\"}\n" +
+	"\rShortenResult\x12%\n" +
+	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12\x1b\n" +
+	"\tshort_url\x18\x02 \x01(\tR\bshortUrl\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"O\n" +
+	"\x15ShortenStreamResponse\x126\n" +
+	"\aresults\x18\x01 \x03(\v2\x1c.url.shortener.ShortenResultR\aresults2\xd7\x06\n" +
+	"\x10ShortenerService\x12Q\n" +
+	"\n" +
+	"ShortenURL\x12 .url.shortener.URLShortenRequest\x1a!.url.shortener.URLShortenResponse\x12N\n" +
+	"\tExpandURL\x12\x1f.url.shortener.URLExpandRequest\x1a .url.shortener.URLExpandResponse\x12G\n" +
+	"\fListUserURLs\x12\x16.google.protobuf.Empty\x1a\x1f.url.shortener.UserURLsResponse\x12L\n" +
+	"\n" +
+	"IssueToken\x12 .url.shortener.IssueTokenRequest\x1a\x1c.url.shortener.TokenResponse\x12P\n" +
+	"\fRefreshToken\x12\".url.shortener.RefreshTokenRequest\x1a\x1c.url.shortener.TokenResponse\x12W\n" +
+	"\fShortenBatch\x12\".url.shortener.ShortenBatchRequest\x1a#.url.shortener.ShortenBatchResponse\x12N\n" +
+	"\x0eDeleteUserURLs\x12$.url.shortener.DeleteUserURLsRequest\x1a\x16.google.protobuf.Empty\x12@\n" +
+	"\bGetStats\x12\x16.google.protobuf.Empty\x1a\x1c.url.shortener.StatsResponse\x126\n" +
+	"\x04Ping\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x12P\n" +
+	"\rShortenStream\x12\x17.url.shortener.BatchURL\x1a$.url.shortener.ShortenStreamResponse(\x01\x12B\n" +
+	"\x0eStreamUserURLs\x12\x16.google.protobuf.Empty\x1a\x16.url.shortener.URLData0\x01B/Z-github.com/noedaka/go-url-shortener/api/protob\beditionsp\xe8\a"
+
+var file_proto_service_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_proto_service_proto_goTypes = []any{
-	(*URLShortenRequest)(nil),  // 0: url.shortener.URLShortenRequest
-	(*URLShortenResponse)(nil), // 1: url.shortener.URLShortenResponse
-	(*URLExpandRequest)(nil),   // 2: url.shortener.URLExpandRequest
-	(*URLExpandResponse)(nil),  // 3: url.shortener.URLExpandResponse
-	(*UserURLsResponse)(nil),   // 4: url.shortener.UserURLsResponse
-	(*URLData)(nil),            // 5: url.shortener.URLData
-	(*emptypb.Empty)(nil),      // 6: google.protobuf.Empty
+	(*URLShortenRequest)(nil),     // 0: url.shortener.URLShortenRequest
+	(*URLShortenResponse)(nil),    // 1: url.shortener.URLShortenResponse
+	(*URLExpandRequest)(nil),      // 2: url.shortener.URLExpandRequest
+	(*URLExpandResponse)(nil),     // 3: url.shortener.URLExpandResponse
+	(*UserURLsResponse)(nil),      // 4: url.shortener.UserURLsResponse
+	(*URLData)(nil),               // 5: url.shortener.URLData
+	(*IssueTokenRequest)(nil),     // 6: url.shortener.IssueTokenRequest
+	(*RefreshTokenRequest)(nil),   // 7: url.shortener.RefreshTokenRequest
+	(*TokenResponse)(nil),         // 8: url.shortener.TokenResponse
+	(*BatchURL)(nil),              // 9: url.shortener.BatchURL
+	(*ShortenBatchRequest)(nil),   // 10: url.shortener.ShortenBatchRequest
+	(*BatchShortURL)(nil),         // 11: url.shortener.BatchShortURL
+	(*ShortenBatchResponse)(nil),  // 12: url.shortener.ShortenBatchResponse
+	(*DeleteUserURLsRequest)(nil), // 13: url.shortener.DeleteUserURLsRequest
+	(*StatsResponse)(nil),         // 14: url.shortener.StatsResponse
+	(*ShortenResult)(nil),         // 15: url.shortener.ShortenResult
+	(*ShortenStreamResponse)(nil), // 16: url.shortener.ShortenStreamResponse
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 18: google.protobuf.Empty
 }
 var file_proto_service_proto_depIdxs = []int32{
-	5, // 0: url.shortener.UserURLsResponse.url:type_name -> url.shortener.URLData
-	0, // 1: url.shortener.ShortenerService.ShortenURL:input_type -> url.shortener.URLShortenRequest
-	2, // 2: url.shortener.ShortenerService.ExpandURL:input_type -> url.shortener.URLExpandRequest
-	6, // 3: url.shortener.ShortenerService.ListUserURLs:input_type -> google.protobuf.Empty
-	1, // 4: url.shortener.ShortenerService.ShortenURL:output_type -> url.shortener.URLShortenResponse
-	3, // 5: url.shortener.ShortenerService.ExpandURL:output_type -> url.shortener.URLExpandResponse
-	4, // 6: url.shortener.ShortenerService.ListUserURLs:output_type -> url.shortener.UserURLsResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	17, // 0: url.shortener.URLShortenRequest.expires_at:type_name -> google.protobuf.Timestamp
+	5,  // 1: url.shortener.UserURLsResponse.url:type_name -> url.shortener.URLData
+	17, // 2: url.shortener.TokenResponse.expires_at:type_name -> google.protobuf.Timestamp
+	17, // 3: url.shortener.BatchURL.expires_at:type_name -> google.protobuf.Timestamp
+	9,  // 4: url.shortener.ShortenBatchRequest.urls:type_name -> url.shortener.BatchURL
+	11, // 5: url.shortener.ShortenBatchResponse.urls:type_name -> url.shortener.BatchShortURL
+	15, // 6: url.shortener.ShortenStreamResponse.results:type_name -> url.shortener.ShortenResult
+	0,  // 7: url.shortener.ShortenerService.ShortenURL:input_type -> url.shortener.URLShortenRequest
+	2,  // 8: url.shortener.ShortenerService.ExpandURL:input_type -> url.shortener.URLExpandRequest
+	18, // 9: url.shortener.ShortenerService.ListUserURLs:input_type -> google.protobuf.Empty
+	6,  // 10: url.shortener.ShortenerService.IssueToken:input_type -> url.shortener.IssueTokenRequest
+	7,  // 11: url.shortener.ShortenerService.RefreshToken:input_type -> url.shortener.RefreshTokenRequest
+	10, // 12: url.shortener.ShortenerService.ShortenBatch:input_type -> url.shortener.ShortenBatchRequest
+	13, // 13: url.shortener.ShortenerService.DeleteUserURLs:input_type -> url.shortener.DeleteUserURLsRequest
+	18, // 14: url.shortener.ShortenerService.GetStats:input_type -> google.protobuf.Empty
+	18, // 15: url.shortener.ShortenerService.Ping:input_type -> google.protobuf.Empty
+	9,  // 16: url.shortener.ShortenerService.ShortenStream:input_type -> url.shortener.BatchURL
+	18, // 17: url.shortener.ShortenerService.StreamUserURLs:input_type -> google.protobuf.Empty
+	1,  // 18: url.shortener.ShortenerService.ShortenURL:output_type -> url.shortener.URLShortenResponse
+	3,  // 19: url.shortener.ShortenerService.ExpandURL:output_type -> url.shortener.URLExpandResponse
+	4,  // 20: url.shortener.ShortenerService.ListUserURLs:output_type -> url.shortener.UserURLsResponse
+	8,  // 21: url.shortener.ShortenerService.IssueToken:output_type -> url.shortener.TokenResponse
+	8,  // 22: url.shortener.ShortenerService.RefreshToken:output_type -> url.shortener.TokenResponse
+	12, // 23: url.shortener.ShortenerService.ShortenBatch:output_type -> url.shortener.ShortenBatchResponse
+	18, // 24: url.shortener.ShortenerService.DeleteUserURLs:output_type -> google.protobuf.Empty
+	14, // 25: url.shortener.ShortenerService.GetStats:output_type -> url.shortener.StatsResponse
+	18, // 26: url.shortener.ShortenerService.Ping:output_type -> google.protobuf.Empty
+	16, // 27: url.shortener.ShortenerService.ShortenStream:output_type -> url.shortener.ShortenStreamResponse
+	5,  // 28: url.shortener.ShortenerService.StreamUserURLs:output_type -> url.shortener.URLData
+	18, // [18:29] is the sub-list for method output_type
+	7,  // [7:18] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_proto_service_proto_init() }
@@ -564,7 +1819,7 @@ func file_proto_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_service_proto_rawDesc), len(file_proto_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -1,78 +1,196 @@
+// Утилита tls выпускает сертификаты для локального запуска сервиса с TLS и mTLS.
+//
+// Использование:
+//
+//	tls ca          [-out certs] [-cn name] [-key-type ecdsa|ed25519|rsa] [-valid-for 87600h]
+//	tls server      [-out certs] [-hosts localhost,127.0.0.1,::1] [-ca certs/ca.pem] [-ca-key certs/ca-key.pem] ...
+//	tls client      [-out certs] [-cn shortener-client] [-ca certs/ca.pem] [-ca-key certs/ca-key.pem] ...
+//	tls self-signed [-out certs] [-hosts localhost,127.0.0.1,::1] ...
+//	tls info        cert.pem...
 package main
 
 import (
-	"bytes"
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
-	"log"
-	"math/big"
-	"net"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/certgen"
+)
+
+const (
+	defaultCAValidity   = 10 * 365 * 24 * time.Hour
+	defaultLeafValidity = 365 * 24 * time.Hour
+	defaultHosts        = "localhost,127.0.0.1,::1"
 )
 
 func main() {
-	cert := &x509.Certificate{
-		SerialNumber: big.NewInt(1658),
-		Subject: pkix.Name{
-			Organization: []string{"url-shortener"},
-			Country:      []string{"RU"},
-		},
-		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
-		NotBefore:    time.Now(),
-		NotAfter:     time.Now().AddDate(10, 0, 0),
-		SubjectKeyId: []byte{1, 2, 3, 4, 6},
-		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:     x509.KeyUsageDigitalSignature,
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
-	if err != nil {
-		log.Fatal(err)
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "ca":
+		err = runCA(args)
+	case "server", "client", "self-signed":
+		err = runIssue(cmd, args)
+	case "info":
+		err = runInfo(args)
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		usage()
+		os.Exit(2)
 	}
 
-	certBytes, err := x509.CreateCertificate(rand.Reader, cert, cert, &privateKey.PublicKey, privateKey)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
 	}
+}
 
-	var certPEM bytes.Buffer
-	err = pem.Encode(&certPEM, &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: certBytes,
-	})
-	if err != nil {
-		log.Fatal(err)
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: tls <command> [flags]
+
+Commands:
+  ca           create a local certificate authority
+  server       issue a server certificate signed by the CA
+  client       issue a client certificate signed by the CA (for mTLS)
+  self-signed  issue a self-signed server certificate without a CA
+  info         print information about certificates
+
+Run "tls <command> -h" for command flags.
+`)
+}
+
+// commonFlags - флаги, общие для команд выпуска сертификатов.
+type commonFlags struct {
+	out      string
+	keyType  string
+	validFor time.Duration
+}
+
+func (c *commonFlags) bind(fs *flag.FlagSet, validity time.Duration) {
+	fs.StringVar(&c.out, "out", "certs", "Output directory")
+	fs.StringVar(&c.keyType, "key-type", certgen.KeyECDSA, "Key type: ecdsa, ed25519 or rsa")
+	fs.DurationVar(&c.validFor, "valid-for", validity, "Certificate validity period")
+}
+
+func runCA(args []string) error {
+	fs := flag.NewFlagSet("ca", flag.ExitOnError)
+	var common commonFlags
+	common.bind(fs, defaultCAValidity)
+	cn := fs.String("cn", "url-shortener local CA", "CA common name")
+	name := fs.String("name", "ca", "Output file name without extension")
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
 
-	var privateKeyPEM bytes.Buffer
-	err = pem.Encode(&privateKeyPEM, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	ca, err := certgen.NewCA(certgen.Request{
+		CommonName: *cn,
+		Validity:   common.validFor,
+		KeyType:    common.keyType,
 	})
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	return write(ca, common.out, *name)
+}
+
+func runIssue(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	var common commonFlags
+	common.bind(fs, defaultLeafValidity)
+
+	certUsage, defaultName, defaultCN := certgen.UsageServer, "server", "localhost"
+	if cmd == "client" {
+		certUsage, defaultName, defaultCN = certgen.UsageClient, "client", "shortener-client"
+	}
+
+	cn := fs.String("cn", defaultCN, "Certificate common name")
+	name := fs.String("name", defaultName, "Output file name without extension")
+	hosts := fs.String("hosts", "", "Comma-separated DNS names and IPs for SAN (server default: "+defaultHosts+")")
+
+	var caFile, caKeyFile *string
+	if cmd != "self-signed" {
+		caFile = fs.String("ca", filepath.Join("certs", "ca.pem"), "CA certificate")
+		caKeyFile = fs.String("ca-key", filepath.Join("certs", "ca-key.pem"), "CA private key")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *hosts == "" && certUsage == certgen.UsageServer {
+		*hosts = defaultHosts
+	}
+
+	var ca *certgen.Certificate
+	if caFile != nil {
+		var err error
+		ca, err = certgen.Load(*caFile, *caKeyFile)
+		if err != nil {
+			return fmt.Errorf("load CA (create one with \"tls ca\"): %w", err)
+		}
 	}
 
-	exeDir, err := os.Getwd()
+	cert, err := certgen.Issue(certgen.Request{
+		CommonName: *cn,
+		Hosts:      certgen.ParseHosts(*hosts),
+		Validity:   common.validFor,
+		KeyType:    common.keyType,
+		Usage:      certUsage,
+	}, ca)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	certsDir := filepath.Join(exeDir, "certs")
+	return write(cert, common.out, *name)
+}
 
-	certPath := filepath.Join(certsDir, "cert.pem")
-	keyPath := filepath.Join(certsDir, "private.pem")
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	if err = os.WriteFile(certPath, certPEM.Bytes(), 0644); err != nil {
-		log.Fatal(err)
+	if fs.NArg() == 0 {
+		return errors.New("no certificate files given")
 	}
 
-	if err = os.WriteFile(keyPath, privateKeyPEM.Bytes(), 0600); err != nil {
-		log.Fatal(err)
+	for i, path := range fs.Args() {
+		certs, err := certgen.ReadCertificates(path)
+		if err != nil {
+			return err
+		}
+
+		for j, cert := range certs {
+			if i > 0 || j > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("File:        %s\n", path)
+			if err := certgen.PrintInfo(os.Stdout, cert); err != nil {
+				return err
+			}
+		}
 	}
+
+	return nil
+}
+
+func write(cert *certgen.Certificate, dir, name string) error {
+	certFile, keyFile, err := cert.Write(dir, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("certificate: %s\nprivate key: %s\n", certFile, keyFile)
+	return nil
 }
@@ -0,0 +1,181 @@
+// Модуль analytics собирает статистику переходов по сокращенным URL.
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// bufferSize - размер очереди переходов, ожидающих записи.
+	bufferSize = 4096
+	// batchSize - максимальное количество переходов в одной записи в хранилище.
+	batchSize = 100
+	// flushInterval - период принудительной записи неполной пачки.
+	flushInterval = time.Second
+	// saveTimeout ограничивает время записи одной пачки.
+	saveTimeout = 5 * time.Second
+
+	// hourlyWindow - период, за который строится почасовая статистика.
+	hourlyWindow = storage.HourlyClickWindow
+	// dailyWindow - количество дней, за которые строится посуточная статистика.
+	dailyWindow = 30
+)
+
+// Recorder асинхронно записывает переходы в хранилище пачками.
+//
+// Record не блокируется: при переполнении очереди переход отбрасывается,
+// чтобы не увеличивать время ответа на редирект.
+type Recorder struct {
+	store  storage.ClickStorage
+	clicks chan model.Click
+	done   chan struct{}
+	// mu защищает clicks от отправки после закрытия: обработчики редиректов
+	// могут еще выполняться, когда Close уже вызван.
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewRecorder создает Recorder и запускает фоновую запись в хранилище.
+func NewRecorder(store storage.ClickStorage) *Recorder {
+	r := &Recorder{
+		store:  store,
+		clicks: make(chan model.Click, bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	go r.run()
+
+	return r
+}
+
+// Record ставит переход в очередь на запись. После Close переходы отбрасываются.
+func (r *Recorder) Record(click model.Click) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		r.dropped.Add(1)
+		return
+	}
+
+	select {
+	case r.clicks <- click:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Dropped возвращает количество отброшенных из-за переполнения очереди или после Close переходов.
+func (r *Recorder) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Stats возвращает статистику переходов по сокращенному URL.
+func (r *Recorder) Stats(ctx context.Context, shortURL string) (*model.ClickStats, error) {
+	now := time.Now().UTC()
+	hourlySince := now.Add(-hourlyWindow).Truncate(time.Hour)
+	dailySince := time.Date(now.Year(), now.Month(), now.Day()-dailyWindow, 0, 0, 0, 0, time.UTC)
+
+	return r.store.GetClickStats(ctx, shortURL, hourlySince, dailySince)
+}
+
+// Close прекращает прием переходов и дожидается записи оставшихся в очереди.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.clicks)
+	}
+	r.mu.Unlock()
+
+	<-r.done
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.Click, 0, batchSize)
+	for {
+		select {
+		case click, ok := <-r.clicks:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+
+			batch = append(batch, click)
+			if len(batch) >= batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *Recorder) flush(batch []model.Click) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+	defer cancel()
+
+	if err := r.store.SaveClicks(ctx, batch); err != nil {
+		logger.Log.Error("failed to save clicks",
+			zap.Error(err),
+			zap.Int("count", len(batch)))
+	}
+}
+
+// NewClick создает переход с огрубленным IP-адресом и идентификатором посетителя.
+func NewClick(shortURL, referrer, userAgent, remoteIP string, ts time.Time) model.Click {
+	ip := CoarseIP(remoteIP)
+	sum := sha256.Sum256([]byte(ip + "|" + userAgent))
+
+	return model.Click{
+		ShortURL:  shortURL,
+		Timestamp: ts.UTC(),
+		Referrer:  referrer,
+		UserAgent: userAgent,
+		IP:        ip,
+		VisitorID: hex.EncodeToString(sum[:8]),
+	}
+}
+
+// CoarseIP обнуляет младшую часть адреса: для IPv4 оставляет сеть /24, для IPv6 - /48.
+//
+// Для некорректного адреса возвращается пустая строка.
+func CoarseIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
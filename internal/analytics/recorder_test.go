@@ -0,0 +1,100 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockClickStorage struct {
+	mu     sync.Mutex
+	clicks []model.Click
+}
+
+func (m *MockClickStorage) SaveClicks(ctx context.Context, clicks []model.Click) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clicks = append(m.clicks, clicks...)
+	return nil
+}
+
+func (m *MockClickStorage) GetClickStats(ctx context.Context, shortURL string, hourlySince, dailySince time.Time) (*model.ClickStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &model.ClickStats{TotalClicks: len(m.clicks)}, nil
+}
+
+func TestRecorder_FlushOnClose(t *testing.T) {
+	store := &MockClickStorage{}
+	recorder := NewRecorder(store)
+
+	for i := 0; i < 3; i++ {
+		recorder.Record(NewClick("abc", "", "curl/8.0", "192.168.1.10:5000", time.Now()))
+	}
+	recorder.Close()
+
+	stats, err := recorder.Stats(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, stats.TotalClicks)
+	assert.Equal(t, int64(0), recorder.Dropped())
+}
+
+func TestRecorder_RecordAfterClose(t *testing.T) {
+	store := &MockClickStorage{}
+	recorder := NewRecorder(store)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				recorder.Record(NewClick("abc", "", "curl/8.0", "192.168.1.10", time.Now()))
+			}
+		}()
+	}
+
+	recorder.Close()
+	wg.Wait()
+	recorder.Close()
+
+	stats, err := recorder.Stats(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(800), int64(stats.TotalClicks)+recorder.Dropped(),
+		"every click must be either saved or counted as dropped")
+}
+
+func TestNewClick_VisitorID(t *testing.T) {
+	now := time.Now()
+	first := NewClick("abc", "", "curl/8.0", "10.0.0.1", now)
+	sameNetwork := NewClick("abc", "", "curl/8.0", "10.0.0.2", now)
+	otherAgent := NewClick("abc", "", "Mozilla/5.0", "10.0.0.1", now)
+
+	assert.Equal(t, first.VisitorID, sameNetwork.VisitorID)
+	assert.NotEqual(t, first.VisitorID, otherAgent.VisitorID)
+	assert.Equal(t, "10.0.0.0", first.IP)
+}
+
+func TestCoarseIP(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want string
+	}{
+		{"IPv4", "203.0.113.57", "203.0.113.0"},
+		{"IPv4 with port", "203.0.113.57:443", "203.0.113.0"},
+		{"IPv6", "2001:db8:abcd:12::1", "2001:db8:abcd::"},
+		{"IPv6 with port", "[2001:db8:abcd:12::1]:443", "2001:db8:abcd::"},
+		{"Invalid", "not-an-ip", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CoarseIP(tt.addr))
+		})
+	}
+}
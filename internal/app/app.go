@@ -2,38 +2,51 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/pprof"
 	"os"
 	"os/signal"
-	"path/filepath"
-	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/noedaka/go-url-shortener/internal/analytics"
 	"github.com/noedaka/go-url-shortener/internal/audit"
+	"github.com/noedaka/go-url-shortener/internal/auth"
+	"github.com/noedaka/go-url-shortener/internal/cache"
 	"github.com/noedaka/go-url-shortener/internal/config"
 	dbc "github.com/noedaka/go-url-shortener/internal/config/db"
 	"github.com/noedaka/go-url-shortener/internal/grpc"
 	"github.com/noedaka/go-url-shortener/internal/handler"
+	"github.com/noedaka/go-url-shortener/internal/health"
 	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/metrics"
 	"github.com/noedaka/go-url-shortener/internal/middleware"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
 	"github.com/noedaka/go-url-shortener/internal/service"
 	"github.com/noedaka/go-url-shortener/internal/storage"
+	"github.com/noedaka/go-url-shortener/internal/tlsutil"
+	"github.com/noedaka/go-url-shortener/internal/tracing"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
+// rootPaths - первые сегменты путей маршрутов сервера. Сокращенные ID с такими
+// именами перекрывались бы маршрутами, поэтому сервис их не выдает.
+// Маршрут верхнего уровня, не указанный здесь, не дает серверу запуститься.
+var rootPaths = []string{"api", "debug", "healthz", "metrics", "ping", "readyz"}
+
 func Run() error {
 	r := chi.NewRouter()
 
-	if err := logger.Init(); err != nil {
-		return err
-	}
-	logger.Log.Sync()
-
 	cfg, err := config.Init()
 	if err != nil {
 		return err
@@ -43,6 +56,29 @@ func Run() error {
 		return err
 	}
 
+	if err := logger.Init(cfg.LogLevel, cfg.LogFormat); err != nil {
+		return err
+	}
+	defer logger.Log.Sync()
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		Insecure:    cfg.TracingInsecure,
+		SampleRatio: cfg.TracingSampleRatio,
+		ServiceName: "go-url-shortener",
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Log.Error("failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	logger.Log.Info("server started",
 		zap.String("address", cfg.ServerAddress),
 		zap.String("base_url", cfg.BaseURL))
@@ -72,6 +108,7 @@ func Run() error {
 
 	var db *sql.DB
 	var store storage.URLStorage
+	var clickStore storage.ClickStorage
 
 	if cfg.HasDatabase {
 		var err error
@@ -81,33 +118,116 @@ func Run() error {
 		}
 		defer db.Close()
 
-		if err := dbc.InitDatabase(db); err != nil {
+		if err := dbc.InitDatabase(context.Background(), db); err != nil {
 			return err
 		}
 
-		store, err = storage.NewPostgresStorage(db)
+		pgStore, err := storage.NewPostgresStorage(db)
 		if err != nil {
 			return err
 		}
+		store, clickStore = pgStore, pgStore
 
 		logger.Log.Info("config inited",
 			zap.String("database dsn", cfg.DatabaseDSN))
 	} else {
-		store = storage.NewFileStorage(cfg.FileStoragePath)
+		fileStore, err := storage.NewFileStorage(cfg.FileStoragePath)
+		if err != nil {
+			return err
+		}
+		defer fileStore.Close()
+		store, clickStore = fileStore, fileStore
+
+		compactCtx, stopCompact := context.WithCancel(context.Background())
+		defer stopCompact()
+		go compactPeriodically(compactCtx, fileStore, cfg.FileCompactInterval)
 		logger.Log.Info("config inited",
 			zap.String("file storage", cfg.FileStoragePath))
 	}
 
-	service := service.NewShortenerService(store, cfg.BaseURL)
-	handlerURL := handler.NewHandler(*service, db)
+	generator, err := newIDGenerator(context.Background(), cfg, store)
+	if err != nil {
+		return err
+	}
+
+	store, closeCache, err := newCachedStorage(context.Background(), cfg, metrics.NewStorage(store))
+	if err != nil {
+		return err
+	}
+	defer closeCache()
+
+	clickRecorder := analytics.NewRecorder(clickStore)
+	defer clickRecorder.Close()
+
+	service := service.NewShortenerService(store, cfg.BaseURL,
+		service.WithIDGenerator(generator),
+		service.WithClickRecorder(clickRecorder),
+		service.WithLimits(cfg.Limits()),
+		service.WithAllowedSchemes(cfg.Schemes()...),
+		service.WithReservedAliases(rootPaths...),
+	)
+	handlerURL := handler.NewHandler(*service, db, handler.WithTrustedSubnet(cfg.TrustedSubnet))
+
+	tokens, err := newTokenService(cfg)
+	if err != nil {
+		return err
+	}
+
+	defaultLimiter, shortenLimiter, closeLimits, err := newRateLimiters(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer closeLimits()
+
+	// limitShorten применяет лимит запросов на сокращение URL, если он задан.
+	limitShorten := func(next http.Handler) http.Handler { return next }
+	if shortenLimiter != nil {
+		limitShorten = middleware.RateLimitMiddleware(shortenLimiter, cfg.RateLimitKey)
+	}
+
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go service.PurgeExpired(purgeCtx, cfg.ExpiredPurgeInterval)
+
+	// internalOnly закрывает служебные маршруты от клиентов вне доверенной подсети,
+	// а при включенном mTLS - и от клиентов без доверенного сертификата.
+	internalOnly := func(r chi.Router) {
+		r.Use(middleware.TrustedSubnetMiddleware(cfg.TrustedSubnet))
+		if cfg.TLSClientCAFile != "" {
+			r.Use(middleware.ClientCertMiddleware)
+		}
+	}
+
+	// Проверки готовности. Проверка gRPC-сервера добавляется после его создания.
+	readiness := health.NewChecker(cfg.ReadinessTimeout)
+	readiness.Add("storage", service.Ping)
+	if auditManager.HasObservers() {
+		readiness.Add("audit", auditManager.Check)
+	}
+
+	r.Get("/healthz", health.LivenessHandler)
+	r.Get("/readyz", health.ReadinessHandler(readiness))
+
+	r.Route("/metrics", func(r chi.Router) {
+		internalOnly(r)
+		r.Get("/", metrics.Handler().ServeHTTP)
+	})
 
 	r.Route("/", func(r chi.Router) {
+		r.Use(middleware.TracingMiddleware)
+		r.Use(middleware.RequestIDMiddleware)
 		r.Use(middleware.LoggingMiddleware)
-		r.Use(middleware.GzipMiddleware)
-		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.MetricsMiddleware)
+		r.Use(middleware.BodyLimitMiddleware(cfg.MaxBodySize))
+		r.Use(middleware.GzipMiddleware(cfg.MaxDecompressedSize))
+		r.Use(middleware.AuthMiddleware(tokens))
+		if defaultLimiter != nil {
+			r.Use(middleware.RateLimitMiddleware(defaultLimiter, cfg.RateLimitKey))
+		}
 		r.Use(middleware.AuditMiddleware(auditManager))
 		r.Route("/api", func(r chi.Router) {
 			r.Route("/shorten", func(r chi.Router) {
+				r.Use(limitShorten)
 				r.Post("/", handlerURL.APIShortenerHandler)
 				r.Post("/batch", handlerURL.ShortenBatchHandler)
 			})
@@ -115,22 +235,15 @@ func Run() error {
 			r.Route("/user/urls", func(r chi.Router) {
 				r.Get("/", handlerURL.APIUserUrlsHandler)
 				r.Delete("/", handlerURL.APIDeleteShortURLSHandler)
+				r.Get("/{id}/stats", handlerURL.APIURLStatsHandler)
 			})
 
 			r.Route("/internal", func(r chi.Router) {
-				r.Use(func(next http.Handler) http.Handler {
-					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						if cfg.TrustedSubnet == "" {
-							http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
-							return
-						}
-						next.ServeHTTP(w, r)
-					})
-				})
-				r.Get("/stats", handlerURL.StatsHandler(cfg.TrustedSubnet))
+				internalOnly(r)
+				r.Get("/stats", handlerURL.StatsHandler)
 			})
 		})
-		r.Post("/", handlerURL.ShortenURLHandler)
+		r.With(limitShorten).Post("/", handlerURL.ShortenURLHandler)
 		r.Get("/{id}", handlerURL.ShortIDHandler)
 		r.Get("/ping", handlerURL.PingDBHandler)
 	})
@@ -149,63 +262,358 @@ func Run() error {
 		r.Get("/allocs", pprof.Handler("allocs").ServeHTTP)
 	})
 
-	GRPCServer := grpc.NewGRPCServer(*cfg, *service)
-	GRPCServer.StartServer()
+	if err := checkRootPaths(r); err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	srv := &http.Server{Addr: cfg.ServerAddress, Handler: r}
+	servers := []*http.Server{srv}
+	grpcOpts := []grpc.Option{
+		grpc.WithRateLimits(defaultLimiter, shortenLimiter, cfg.RateLimitKey),
+		grpc.WithReadiness(readiness),
+	}
 
-	srv := http.Server{Addr: cfg.ServerAddress, Handler: r}
+	if cfg.EnableHTTPS {
+		tlsSetup, err := newTLSSetup(cfg)
+		if err != nil {
+			return err
+		}
+		defer tlsSetup.stop()
 
-	serverErr := make(chan error, 1)
-	go func() {
-		if cfg.EnableHTTPS {
-			certFile, keyFile := getCertPaths()
-			serverErr <- srv.ListenAndServeTLS(certFile, keyFile)
-		} else {
-			serverErr <- srv.ListenAndServe()
+		srv.TLSConfig = tlsSetup.httpConfig
+		grpcOpts = append(grpcOpts, grpc.WithTLS(tlsSetup.grpcConfig))
+
+		if cfg.HTTPRedirectAddr != "" {
+			servers = append(servers, &http.Server{Addr: cfg.HTTPRedirectAddr, Handler: tlsSetup.redirect})
 		}
-	}()
+	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	grpcServer := grpc.NewGRPCServer(*cfg, *service, tokens, grpcOpts...)
+	readiness.Add("grpc", func(context.Context) error {
+		if !grpcServer.Serving() {
+			return errors.New("grpc server is not serving")
+		}
+		return nil
+	})
 
-	select {
-	case err := <-serverErr:
-		// Сервер сам завершился с ошибкой
-		return err
-	case sig := <-sigChan:
-		// Получен сигнал на завершение
-		logger.Log.Info("received signal, starting graceful shutdown",
-			zap.String("signal", sig.String()))
-
-		shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 30*time.Second)
-		defer shutdownCancel()
-
-		if err := srv.Shutdown(shutdownCtx); err != nil {
-			logger.Log.Error("server shutdown error", zap.Error(err))
-			// Принудительное закрытие сервера
-			if err := srv.Close(); err != nil {
-				logger.Log.Error("server force close error", zap.Error(err))
+	return serve(cfg, grpcServer, servers...)
+}
+
+// checkRootPaths проверяет, что первый сегмент каждого маршрута r, кроме
+// параметра сокращенного ID, перечислен в rootPaths.
+func checkRootPaths(r chi.Routes) error {
+	return chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment == "" || strings.HasPrefix(segment, "{") || slices.Contains(rootPaths, segment) {
+			return nil
+		}
+		return fmt.Errorf("route %s %s is not listed in rootPaths", method, route)
+	})
+}
+
+// tlsSetup - конфигурации TLS серверов и обработчик незащищенного HTTP-порта.
+type tlsSetup struct {
+	httpConfig *tls.Config
+	grpcConfig *tls.Config
+	// redirect перенаправляет запросы на HTTPS, а в режиме ACME также отвечает на проверки HTTP-01.
+	redirect http.Handler
+	stop     func()
+}
+
+// newTLSSetup готовит TLS по конфигурации: сертификаты получаются по ACME,
+// если заданы домены, иначе загружаются из файлов и перечитываются по SIGHUP.
+func newTLSSetup(cfg *config.Config) (*tlsSetup, error) {
+	redirect := tlsutil.RedirectHandler(cfg.ServerAddress)
+
+	if cfg.ACMEDomains != "" {
+		domains := tlsutil.ParseDomains(cfg.ACMEDomains)
+		manager, err := tlsutil.NewACMEManager(tlsutil.ACMEConfig{
+			Domains:      domains,
+			CacheDir:     cfg.ACMECacheDir,
+			DirectoryURL: cfg.ACMEDirectoryURL,
+			Email:        cfg.ACMEEmail,
+			CAFile:       cfg.ACMECAFile,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Log.Info("ACME TLS enabled",
+			zap.Strings("domains", domains),
+			zap.String("cache dir", cfg.ACMECacheDir))
+
+		return &tlsSetup{
+			httpConfig: manager.TLSConfig(),
+			grpcConfig: &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: manager.GetCertificate},
+			redirect:   tlsutil.ChallengeHandler(manager, redirect),
+			stop:       func() {},
+		}, nil
+	}
+
+	certs, err := tlsutil.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	go reloadOnSIGHUP(reloadCtx, certs)
+
+	logger.Log.Info("TLS enabled",
+		zap.String("cert file", cfg.TLSCertFile),
+		zap.Bool("mutual TLS", certs.MutualTLS()))
+
+	return &tlsSetup{
+		httpConfig: certs.ServerConfig("h2", "http/1.1"),
+		grpcConfig: certs.ServerConfig(),
+		redirect:   redirect,
+		stop:       stopReload,
+	}, nil
+}
+
+// serve запускает HTTP- и gRPC-серверы и блокируется до их остановки.
+//
+// По сигналу завершения или при ошибке любого из серверов все серверы
+// перестают принимать новые запросы и дожидаются завершения текущих
+// в течение cfg.ShutdownTimeout, после чего закрываются принудительно.
+func serve(cfg *config.Config, grpcServer *grpc.GRPCServer, servers ...*http.Server) error {
+	g, ctx := errgroup.WithContext(context.Background())
+
+	for _, srv := range servers {
+		g.Go(func() error {
+			var err error
+			if srv.TLSConfig != nil {
+				// Сертификаты берутся из srv.TLSConfig.
+				err = srv.ListenAndServeTLS("", "")
+			} else {
+				err = srv.ListenAndServe()
+			}
+
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return fmt.Errorf("http server %s: %w", srv.Addr, err)
+		})
+	}
+
+	g.Go(func() error {
+		if err := grpcServer.Start(); err != nil {
+			return fmt.Errorf("grpc server: %w", err)
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+		defer signal.Stop(sigChan)
+
+		select {
+		case sig := <-sigChan:
+			logger.Log.Info("received signal, starting graceful shutdown",
+				zap.String("signal", sig.String()))
+		case <-ctx.Done():
+			// Один из серверов завершился с ошибкой, останавливаем остальные.
+			logger.Log.Info("server failed, starting graceful shutdown")
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+
+		errs := make([]error, len(servers)+1)
+		var wg sync.WaitGroup
+		for i, srv := range servers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if errs[i] = srv.Shutdown(shutdownCtx); errs[i] != nil {
+					logger.Log.Error("http server shutdown error",
+						zap.String("address", srv.Addr), zap.Error(errs[i]))
+					// Принудительное закрытие сервера
+					if err := srv.Close(); err != nil {
+						logger.Log.Error("http server force close error", zap.Error(err))
+					}
+				}
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[len(servers)] = grpcServer.GracefulStop(shutdownCtx); errs[len(servers)] != nil {
+				logger.Log.Error("grpc server shutdown error", zap.Error(errs[len(servers)]))
 			}
+		}()
+		wg.Wait()
 
+		if err := errors.Join(errs...); err != nil {
 			return err
 		}
 
-		logger.Log.Info("server stopped gracefully")
+		logger.Log.Info("servers stopped gracefully")
+		return nil
+	})
+
+	return g.Wait()
+}
+
+// reloadOnSIGHUP перечитывает TLS-сертификаты при получении SIGHUP до отмены контекста.
+func reloadOnSIGHUP(ctx context.Context, certs *tlsutil.Reloader) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigChan:
+			if err := certs.Reload(); err != nil {
+				logger.Log.Error("failed to reload TLS certificates, keeping previous ones", zap.Error(err))
+				continue
+			}
+			logger.Log.Info("TLS certificates reloaded")
+		}
+	}
+}
+
+// compactPeriodically уплотняет файловое хранилище с заданным интервалом до отмены контекста.
+func compactPeriodically(ctx context.Context, fileStore *storage.FileStorage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fileStore.Compact(ctx); err != nil {
+				logger.Log.Error("failed to compact file storage", zap.Error(err))
+			}
+		}
+	}
+}
+
+// newTokenService создает сервис токенов с ключами подписи из конфигурации.
+//
+// Если ключи не заданы, используется случайный ключ: выданные токены
+// перестают действовать после перезапуска.
+func newTokenService(cfg *config.Config) (*auth.TokenService, error) {
+	keys, err := auth.LoadKeySet(cfg.JWTSecret, cfg.JWTKeys, cfg.JWTKeyFile, cfg.JWTActiveKID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys.Keys) == 0 {
+		logger.Log.Warn("JWT signing keys are not configured, using an ephemeral key")
+
+		keys, err = auth.EphemeralKeySet()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewTokenService(keys, cfg.TokenTTL)
+}
+
+// newCachedStorage оборачивает хранилище кэшем, выбранным в конфигурации.
+//
+// Возвращает функцию освобождения ресурсов кэша.
+func newCachedStorage(ctx context.Context, cfg *config.Config, store storage.URLStorage) (storage.URLStorage, func() error, error) {
+	var backend cache.Backend
+	closeBackend := func() error { return nil }
+
+	switch cfg.CacheBackend {
+	case config.CacheMemory:
+		backend = cache.NewLRU(cfg.CacheSize)
+	case config.CacheRedis:
+		redisCache, err := cache.NewRedisFromURL(ctx, cfg.RedisURL, "shortener:url:")
+		if err != nil {
+			return nil, nil, err
+		}
+		backend, closeBackend = redisCache, redisCache.Close
+	default:
+		return store, closeBackend, nil
 	}
 
-	return nil
+	logger.Log.Info("url cache enabled",
+		zap.String("backend", cfg.CacheBackend),
+		zap.Duration("ttl", cfg.CacheTTL))
+
+	return cache.NewCachedStorage(store, backend, cfg.CacheTTL, cfg.CacheNegativeTTL), closeBackend, nil
 }
 
-func getCertPaths() (certFile, keyFile string) {
-	_, currentFile, _, _ := runtime.Caller(0)
+// newRateLimiters создает общий лимит запросов и лимит запросов на сокращение URL.
+//
+// Отключенный лимит возвращается как nil. Возвращает функцию освобождения ресурсов хранилища.
+func newRateLimiters(ctx context.Context, cfg *config.Config) (*ratelimit.Limiter, *ratelimit.Limiter, func() error, error) {
+	closeStore := func() error { return nil }
+
+	var store ratelimit.Store
+	switch cfg.RateLimitStore {
+	case config.RateLimitMemory:
+		store = ratelimit.NewMemory()
+	case config.RateLimitRedis:
+		redisStore, err := ratelimit.NewRedisFromURL(ctx, cfg.RedisURL, "shortener:ratelimit:")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		store, closeStore = redisStore, redisStore.Close
+	default:
+		return nil, nil, closeStore, nil
+	}
+
+	newLimiter := func(name, spec string) (*ratelimit.Limiter, error) {
+		limit, enabled, err := ratelimit.ParseLimit(spec)
+		if err != nil || !enabled {
+			return nil, err
+		}
+		return ratelimit.NewLimiter(store, name, limit), nil
+	}
+
+	defaultLimiter, err := newLimiter("default", cfg.RateLimitDefault)
+	if err != nil {
+		closeStore()
+		return nil, nil, nil, err
+	}
+
+	shortenLimiter, err := newLimiter("shorten", cfg.RateLimitShorten)
+	if err != nil {
+		closeStore()
+		return nil, nil, nil, err
+	}
+
+	logger.Log.Info("rate limiting enabled",
+		zap.String("store", cfg.RateLimitStore),
+		zap.String("key", cfg.RateLimitKey),
+		zap.String("default", cfg.RateLimitDefault),
+		zap.String("shorten", cfg.RateLimitShorten))
 
-	projectRoot := filepath.Dir(filepath.Dir(filepath.Dir(currentFile)))
+	return defaultLimiter, shortenLimiter, closeStore, nil
+}
 
-	certsDir := filepath.Join(projectRoot, "cmd", "tls", "certs")
-	certFile = filepath.Join(certsDir, "cert.pem")
-	keyFile = filepath.Join(certsDir, "private.pem")
+// newIDGenerator создает генератор сокращенных ID по настройкам конфигурации.
+//
+// Счетчик стратегии counter продолжается с наибольшего значения, полученного
+// декодированием сохраненных ID, включая удаленные и истекшие, чтобы их ID не
+// выдавались повторно. Для этого хранилище просматривается целиком один раз при запуске.
+func newIDGenerator(ctx context.Context, cfg *config.Config, store storage.URLStorage) (service.IDGenerator, error) {
+	var counterStart uint64
+	if cfg.ShortIDStrategy == service.StrategyCounter {
+		iterator, ok := store.(storage.ShortIDIterator)
+		if !ok {
+			return nil, errors.New("storage does not support the counter id strategy")
+		}
+
+		err := iterator.IterateShortIDs(ctx, func(shortID string) error {
+			if n, ok := service.DecodeCounter(shortID, cfg.ShortIDAlphabet); ok && n > counterStart {
+				counterStart = n
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return
+	return service.NewIDGenerator(cfg.ShortIDStrategy, cfg.ShortIDAlphabet, cfg.ShortIDLength, counterStart)
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/migrator"
+	"github.com/noedaka/go-url-shortener/migrations"
+)
+
+// migrateUsage описывает синтаксис подкоманды migrate.
+const migrateUsage = "usage: shortener migrate up|down [steps]|status [flags]"
+
+// Migrate выполняет подкоманду migrate.
+//
+// Первый аргумент - действие (up, down или status), для down можно указать
+// количество откатываемых миграций. Остальные аргументы - обычные флаги сервера,
+// из которых используется только DSN базы данных.
+func Migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	action, args := args[0], args[1:]
+
+	steps := 1
+	if action == "down" && len(args) > 0 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			if n <= 0 {
+				return fmt.Errorf("invalid number of steps: %d", n)
+			}
+			steps, args = n, args[1:]
+		}
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	if err := logger.Init(cfg.LogLevel, cfg.LogFormat); err != nil {
+		return err
+	}
+
+	if cfg.DatabaseDSN == "" {
+		return errors.New("database DSN is required for migrations")
+	}
+
+	db, err := sql.Open("pgx", cfg.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+	case "down":
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", n)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(os.Stdout, statuses)
+	default:
+		return fmt.Errorf("unknown migrate action %q; %s", action, migrateUsage)
+	}
+
+	return nil
+}
+
+func printMigrationStatus(out io.Writer, statuses []migrator.Status) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+
+	return w.Flush()
+}
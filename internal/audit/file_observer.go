@@ -1,6 +1,7 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -44,6 +45,29 @@ func (o *FileObserver) Notify(event model.AuditEvent) error {
 	return nil
 }
 
+// Check проверяет, что файл аудита открыт и не был удален или подменен:
+// иначе события пишутся в файл, которого больше нет по пути filePath.
+func (o *FileObserver) Check(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	opened, err := o.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat audit file: %w", err)
+	}
+
+	current, err := os.Stat(o.filePath)
+	if err != nil {
+		return fmt.Errorf("stat audit file: %w", err)
+	}
+
+	if !os.SameFile(opened, current) {
+		return fmt.Errorf("audit file %s was replaced", o.filePath)
+	}
+
+	return nil
+}
+
 func (o *FileObserver) Close() error {
 	if o.file != nil {
 		return o.file.Close()
@@ -2,8 +2,10 @@ package audit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -43,6 +45,27 @@ func (o *HTTPObserver) Notify(event model.AuditEvent) error {
 	return nil
 }
 
+// Check проверяет, что сервер аудита отвечает. Запрос HEAD не создает событий,
+// поэтому ошибкой считаются только сетевые ошибки и ответы 5xx.
+func (o *HTTPObserver) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, o.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: status %d", ErrHTTPRequestFailed, resp.StatusCode)
+	}
+
+	return nil
+}
+
 func (o *HTTPObserver) Close() error {
 	// HTTP клиент не требует закрытия
 	return nil
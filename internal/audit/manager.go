@@ -1,8 +1,12 @@
 package audit
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 
+	"github.com/noedaka/go-url-shortener/internal/metrics"
 	"github.com/noedaka/go-url-shortener/internal/model"
 )
 
@@ -43,11 +47,58 @@ func (m *AuditManager) NotifyObservers(event model.AuditEvent) {
 
 	for _, observer := range observers {
 		go func(obs Observer) {
-			_ = obs.Notify(event)
+			result := metrics.AuditSuccess
+			if err := obs.Notify(event); err != nil {
+				result = metrics.AuditFailure
+			}
+			metrics.AuditDeliveries.WithLabelValues(observerName(obs), result).Inc()
 		}(observer)
 	}
 }
 
+// observerName возвращает имя наблюдателя для метрик.
+func observerName(observer Observer) string {
+	switch observer.(type) {
+	case *FileObserver:
+		return "file"
+	case *HTTPObserver:
+		return "http"
+	}
+
+	return "other"
+}
+
+// Check проверяет доступность всех наблюдателей, реализующих HealthChecker.
+// Ошибка содержит имена недоступных наблюдателей.
+func (m *AuditManager) Check(ctx context.Context) error {
+	m.mu.RLock()
+	observers := make([]Observer, len(m.observers))
+	copy(observers, m.observers)
+	m.mu.RUnlock()
+
+	var errs []error
+	for _, observer := range observers {
+		checker, ok := observer.(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		if err := checker.Check(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", observerName(observer), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// HasObservers сообщает, зарегистрирован ли хотя бы один наблюдатель.
+func (m *AuditManager) HasObservers() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.observers) > 0
+}
+
 func (m *AuditManager) Close() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -1,12 +1,23 @@
 package audit
 
-import "github.com/noedaka/go-url-shortener/internal/model"
+import (
+	"context"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+)
 
 type Observer interface {
 	Notify(event model.AuditEvent) error
 	Close() error
 }
 
+// HealthChecker реализуется наблюдателями, умеющими проверить доступность
+// получателя событий аудита.
+type HealthChecker interface {
+	// Check возвращает ошибку, если события аудита сейчас доставить нельзя.
+	Check(ctx context.Context) error
+}
+
 type Subject interface {
 	RegisterObserver(observer Observer)
 	RemoveObserver(observer Observer)
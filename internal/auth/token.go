@@ -0,0 +1,254 @@
+// Модуль auth выпускает и проверяет JWT-токены пользователей.
+//
+// Один TokenService используется и HTTP-сервером, и gRPC-сервером.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/noedaka/go-url-shortener/internal/model"
+)
+
+const (
+	// DefaultTokenTTL - время жизни токена по умолчанию.
+	DefaultTokenTTL = 24 * time.Hour
+	// MinSecretLength - минимальная длина секрета HS256 в байтах.
+	MinSecretLength = 32
+	// defaultKeyID - идентификатор ключа, заданного одиночным секретом.
+	defaultKeyID = "default"
+)
+
+var (
+	// ErrNoKeys возвращается, если не задан ни один ключ подписи.
+	ErrNoKeys = errors.New("no signing keys configured")
+	// ErrUnknownKey возвращается, если токен подписан неизвестным ключом.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrInvalidToken возвращается для недействительного токена.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Key - ключ подписи токенов с идентификатором, записываемым в заголовок kid.
+type Key struct {
+	ID     string `json:"kid"`
+	Secret string `json:"secret"`
+}
+
+// KeySet содержит ключи подписи и идентификатор ключа, которым подписываются новые токены.
+//
+// Остальные ключи используются только для проверки, что позволяет сменить
+// активный ключ, не инвалидируя выпущенные ранее токены.
+type KeySet struct {
+	ActiveID string `json:"active_kid"`
+	Keys     []Key  `json:"keys"`
+}
+
+// TokenService выпускает и проверяет токены, подписанные HS256.
+type TokenService struct {
+	keys     map[string][]byte
+	activeID string
+	ttl      time.Duration
+}
+
+// NewTokenService создает TokenService. Если ActiveID не задан, активным считается первый ключ.
+func NewTokenService(set KeySet, ttl time.Duration) (*TokenService, error) {
+	if len(set.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	keys := make(map[string][]byte, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.ID == "" {
+			return nil, errors.New("signing key id must not be empty")
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		if len(key.Secret) < MinSecretLength {
+			return nil, fmt.Errorf("signing key %q is shorter than %d bytes", key.ID, MinSecretLength)
+		}
+
+		keys[key.ID] = []byte(key.Secret)
+	}
+
+	activeID := set.ActiveID
+	if activeID == "" {
+		activeID = set.Keys[0].ID
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, activeID)
+	}
+
+	return &TokenService{keys: keys, activeID: activeID, ttl: ttl}, nil
+}
+
+// Issue выпускает токен для пользователя, подписанный активным ключом.
+func (s *TokenService) Issue(userID string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.ttl)
+
+	claims := &model.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = s.activeID
+
+	signed, err := token.SignedString(s.keys[s.activeID])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Parse проверяет токен и возвращает идентификатор пользователя.
+//
+// Ключ выбирается по заголовку kid, токены без kid проверяются активным ключом.
+func (s *TokenService) Parse(tokenStr string) (string, error) {
+	claims := &model.Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, s.keyFunc)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if !token.Valid || claims.UserID == "" {
+		return "", ErrInvalidToken
+	}
+
+	return claims.UserID, nil
+}
+
+// Refresh выпускает новый токен для пользователя из tokenStr.
+//
+// Принимаются токены, срок действия которых истек не раньше, чем TTL назад,
+// чтобы клиент мог продлить сессию после перерыва в работе.
+// Новый токен подписывается текущим активным ключом.
+func (s *TokenService) Refresh(tokenStr string) (string, string, time.Time, error) {
+	claims := &model.Claims{}
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenStr, claims, s.keyFunc)
+	if err != nil {
+		return "", "", time.Time{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if !token.Valid || claims.UserID == "" || claims.ExpiresAt == nil {
+		return "", "", time.Time{}, ErrInvalidToken
+	}
+
+	if time.Since(claims.ExpiresAt.Time) > s.ttl {
+		return "", "", time.Time{}, fmt.Errorf("%w: token expired too long ago", ErrInvalidToken)
+	}
+
+	signed, expiresAt, err := s.Issue(claims.UserID)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return signed, claims.UserID, expiresAt, nil
+}
+
+func (s *TokenService) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodHS256 {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = s.activeID
+	}
+
+	secret, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	return secret, nil
+}
+
+// TTL возвращает время жизни выпускаемых токенов.
+func (s *TokenService) TTL() time.Duration {
+	return s.ttl
+}
+
+// LoadKeySet собирает ключи из одиночного секрета, списка вида "kid:secret,kid:secret"
+// и JSON-файла в формате KeySet. Ключи из всех источников объединяются.
+//
+// activeID переопределяет активный ключ; если он пуст, используется active_kid
+// из файла, иначе - первый ключ списка.
+func LoadKeySet(secret, keys, keyFile, activeID string) (KeySet, error) {
+	var set KeySet
+
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return KeySet{}, err
+		}
+		if err := json.Unmarshal(data, &set); err != nil {
+			return KeySet{}, fmt.Errorf("parse key file %s: %w", keyFile, err)
+		}
+	}
+
+	if keys != "" {
+		parsed, err := ParseKeys(keys)
+		if err != nil {
+			return KeySet{}, err
+		}
+		set.Keys = append(parsed, set.Keys...)
+	}
+
+	if secret != "" {
+		set.Keys = append([]Key{{ID: defaultKeyID, Secret: secret}}, set.Keys...)
+	}
+
+	if activeID != "" {
+		set.ActiveID = activeID
+	}
+
+	return set, nil
+}
+
+// ParseKeys разбирает список ключей вида "kid:secret,kid:secret".
+func ParseKeys(s string) ([]Key, error) {
+	var keys []Key
+	for i, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Секрет не попадает в текст ошибки.
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid signing key #%d: expected kid:secret", i+1)
+		}
+
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+
+	return keys, nil
+}
+
+// EphemeralKeySet создает случайный ключ, действующий до перезапуска процесса.
+func EphemeralKeySet() (KeySet, error) {
+	secret := make([]byte, MinSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return KeySet{}, err
+	}
+
+	return KeySet{Keys: []Key{{ID: "ephemeral", Secret: hex.EncodeToString(secret)}}}, nil
+}
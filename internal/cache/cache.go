@@ -0,0 +1,17 @@
+// Модуль cache реализует кэширование сокращенных URL перед хранилищем.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend хранит строковые значения с ограниченным временем жизни.
+type Backend interface {
+	// Get возвращает значение по ключу. Второе значение равно false, если ключа нет.
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set сохраняет значение на время ttl.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Delete удаляет значения по ключам.
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU - кэш в памяти процесса с ограниченным количеством записей.
+//
+// При переполнении вытесняется запись, к которой дольше всего не обращались.
+// Записи с истекшим временем жизни удаляются при обращении к ним.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type lruItem struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// NewLRU создает LRU, хранящий не более capacity записей.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get возвращает значение по ключу и помечает запись как недавно использованную.
+func (c *LRU) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	item := el.Value.(*lruItem)
+	if !c.now().Before(item.expiresAt) {
+		c.removeElement(el)
+		return "", false, nil
+	}
+
+	c.order.MoveToFront(el)
+
+	return item.value, true, nil
+}
+
+// Set сохраняет значение на время ttl, вытесняя самую старую запись при переполнении.
+func (c *LRU) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+
+	if el, ok := c.items[key]; ok {
+		item := el.Value.(*lruItem)
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+
+	return nil
+}
+
+// Delete удаляет значения по ключам.
+func (c *LRU) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+
+	return nil
+}
+
+// Len возвращает количество записей в кэше, включая еще не удаленные истекшие.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// removeElement удаляет запись из списка и индекса. Вызывается под c.mu.
+func (c *LRU) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*lruItem).key)
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	assert.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	assert.NoError(t, c.Set(ctx, "b", "2", time.Minute))
+
+	// Обращение к "a" делает самой старой запись "b".
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "c", "3", time.Minute))
+	assert.Equal(t, 2, c.Len())
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry must be evicted")
+
+	value, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+}
+
+func TestLRU_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	assert.NoError(t, c.Set(ctx, "a", "1", time.Second))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRU_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	assert.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	assert.NoError(t, c.Set(ctx, "b", "2", time.Minute))
+	assert.NoError(t, c.Delete(ctx, "a", "missing"))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "b")
+	assert.True(t, ok)
+}
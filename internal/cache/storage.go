@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/storage"
+	"go.uber.org/zap"
+)
+
+// CachedStorage кэширует результаты Get поверх другого хранилища.
+//
+// Кэшируются и найденные URL, и отсутствующие (на время negativeTTL).
+// Запись сбрасывается при сохранении и удалении сокращенного URL.
+// Ошибки кэша не прерывают запрос: в этом случае данные читаются из хранилища.
+type CachedStorage struct {
+	storage.URLStorage
+
+	backend     Backend
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// cachedEntry - значение, сохраняемое в кэше.
+type cachedEntry struct {
+	model.URLEntry
+	// Missing означает, что сокращенного URL нет в хранилище.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// NewCachedStorage создает кэширующую обертку над store.
+//
+// Найденные URL хранятся в кэше не дольше ttl и не дольше срока их действия,
+// отсутствующие - negativeTTL.
+func NewCachedStorage(store storage.URLStorage, backend Backend, ttl, negativeTTL time.Duration) *CachedStorage {
+	return &CachedStorage{
+		URLStorage:  store,
+		backend:     backend,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// Get возвращает оригинальный URL из кэша или из хранилища.
+func (c *CachedStorage) Get(ctx context.Context, shortURL string) (string, error) {
+	if entry, ok := c.lookup(ctx, shortURL); ok {
+		c.hits.Add(1)
+		return entry.resolve()
+	}
+	c.misses.Add(1)
+
+	entry, err := c.load(ctx, shortURL)
+	if errors.Is(err, model.ErrShortURLNotFound) {
+		c.store(ctx, shortURL, cachedEntry{Missing: true}, c.negativeTTL)
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ttl := c.ttl
+	if entry.ExpiresAt != nil {
+		if untilExpiry := time.Until(*entry.ExpiresAt); untilExpiry > 0 && untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	c.store(ctx, shortURL, entry, ttl)
+
+	return entry.resolve()
+}
+
+// Save сохраняет URL и сбрасывает кэшированный результат поиска по нему.
+func (c *CachedStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	if err := c.URLStorage.Save(ctx, shortURL, originalURL, userID, expiresAt); err != nil {
+		return err
+	}
+
+	c.invalidate(ctx, shortURL)
+
+	return nil
+}
+
+// DeleteByUser удаляет URL пользователя и сбрасывает их записи в кэше.
+//
+// Кэш сбрасывается и при ошибке, так как часть URL могла быть удалена.
+func (c *CachedStorage) DeleteByUser(ctx context.Context, userID string, shortURL []string) error {
+	err := c.URLStorage.DeleteByUser(ctx, userID, shortURL)
+	c.invalidate(ctx, shortURL...)
+
+	return err
+}
+
+// IterateByUser обходит URL пользователя в хранилище, минуя кэш.
+func (c *CachedStorage) IterateByUser(ctx context.Context, userID string, fn func(model.URLPair) error) error {
+	return storage.IterateByUser(ctx, c.URLStorage, userID, fn)
+}
+
+// GetStats возвращает статистику хранилища вместе со счетчиками кэша.
+func (c *CachedStorage) GetStats(ctx context.Context) (*model.Stats, error) {
+	stats, err := c.URLStorage.GetStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheStats := c.Stats()
+	stats.Cache = &cacheStats
+
+	return stats, nil
+}
+
+// Stats возвращает количество попаданий и промахов кэша.
+func (c *CachedStorage) Stats() model.CacheStats {
+	return model.CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+func (c *CachedStorage) lookup(ctx context.Context, shortURL string) (cachedEntry, bool) {
+	value, ok, err := c.backend.Get(ctx, shortURL)
+	if err != nil {
+		logger.FromContext(ctx).Warn("cache get failed", zap.Error(err), zap.String("short_url", shortURL))
+		return cachedEntry{}, false
+	}
+	if !ok {
+		return cachedEntry{}, false
+	}
+
+	var entry cachedEntry
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return cachedEntry{}, false
+	}
+
+	return entry, true
+}
+
+// load читает запись из хранилища. Если хранилище не возвращает запись целиком,
+// она восстанавливается по результату Get.
+func (c *CachedStorage) load(ctx context.Context, shortURL string) (cachedEntry, error) {
+	if getter, ok := c.URLStorage.(storage.EntryGetter); ok {
+		entry, err := getter.GetEntry(ctx, shortURL)
+		if err != nil {
+			return cachedEntry{}, err
+		}
+		return cachedEntry{URLEntry: *entry}, nil
+	}
+
+	originalURL, err := c.URLStorage.Get(ctx, shortURL)
+	switch {
+	case errors.Is(err, model.ErrURLExpired):
+		expiredAt := time.Now()
+		return cachedEntry{URLEntry: model.URLEntry{ExpiresAt: &expiredAt}}, nil
+	case err != nil:
+		return cachedEntry{}, err
+	case originalURL == "":
+		return cachedEntry{URLEntry: model.URLEntry{IsDeleted: true}}, nil
+	}
+
+	return cachedEntry{URLEntry: model.URLEntry{OriginalURL: originalURL}}, nil
+}
+
+func (c *CachedStorage) store(ctx context.Context, shortURL string, entry cachedEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if err := c.backend.Set(ctx, shortURL, string(value), ttl); err != nil {
+		logger.FromContext(ctx).Warn("cache set failed", zap.Error(err), zap.String("short_url", shortURL))
+	}
+}
+
+// invalidate удаляет записи из кэша даже после отмены ctx, чтобы не оставлять устаревшие данные.
+func (c *CachedStorage) invalidate(ctx context.Context, shortURLs ...string) {
+	if err := c.backend.Delete(context.WithoutCancel(ctx), shortURLs...); err != nil {
+		logger.FromContext(ctx).Error("cache invalidation failed", zap.Error(err), zap.Strings("short_urls", shortURLs))
+	}
+}
+
+func (e cachedEntry) resolve() (string, error) {
+	if e.Missing {
+		return "", model.ErrShortURLNotFound
+	}
+
+	return e.URLEntry.Resolve(time.Now())
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+type MockStorage struct {
+	entries map[string]*model.URLEntry
+	gets    int
+}
+
+func NewMockStorage() *MockStorage {
+	return &MockStorage{entries: make(map[string]*model.URLEntry)}
+}
+
+func (m *MockStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	m.entries[shortURL] = &model.URLEntry{OriginalURL: originalURL, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (m *MockStorage) Get(ctx context.Context, shortURL string) (string, error) {
+	entry, err := m.GetEntry(ctx, shortURL)
+	if err != nil {
+		return "", err
+	}
+	return entry.Resolve(time.Now())
+}
+
+func (m *MockStorage) GetEntry(ctx context.Context, shortURL string) (*model.URLEntry, error) {
+	m.gets++
+	entry, ok := m.entries[shortURL]
+	if !ok {
+		return nil, model.ErrShortURLNotFound
+	}
+	return entry, nil
+}
+
+func (m *MockStorage) GetByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
+	return nil, nil
+}
+
+func (m *MockStorage) DeleteByUser(ctx context.Context, userID string, shortURLs []string) error {
+	for _, shortURL := range shortURLs {
+		if entry, ok := m.entries[shortURL]; ok {
+			entry.IsDeleted = true
+		}
+	}
+	return nil
+}
+
+func (m *MockStorage) GetStats(ctx context.Context) (*model.Stats, error) {
+	return &model.Stats{URLs: len(m.entries)}, nil
+}
+
+func (m *MockStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+func newBackends(t *testing.T) map[string]Backend {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return map[string]Backend{
+		"memory": NewLRU(100),
+		"redis":  NewRedis(client, "test:"),
+	}
+}
+
+func TestCachedStorage_Get(t *testing.T) {
+	ctx := context.Background()
+
+	for name, backend := range newBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := NewMockStorage()
+			cached := NewCachedStorage(store, backend, time.Minute, time.Minute)
+
+			assert.NoError(t, cached.Save(ctx, "abc", "https://example.com", "user1", nil))
+
+			for i := 0; i < 3; i++ {
+				url, err := cached.Get(ctx, "abc")
+				assert.NoError(t, err)
+				assert.Equal(t, "https://example.com", url)
+			}
+
+			assert.Equal(t, 1, store.gets)
+			assert.Equal(t, model.CacheStats{Hits: 2, Misses: 1}, cached.Stats())
+
+			stats, err := cached.GetStats(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, &model.CacheStats{Hits: 2, Misses: 1}, stats.Cache)
+		})
+	}
+}
+
+func TestCachedStorage_NegativeLookup(t *testing.T) {
+	ctx := context.Background()
+
+	for name, backend := range newBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := NewMockStorage()
+			cached := NewCachedStorage(store, backend, time.Minute, time.Minute)
+
+			_, err := cached.Get(ctx, "missing")
+			assert.ErrorIs(t, err, model.ErrShortURLNotFound)
+			_, err = cached.Get(ctx, "missing")
+			assert.ErrorIs(t, err, model.ErrShortURLNotFound)
+			assert.Equal(t, 1, store.gets, "missing lookup must be cached")
+
+			// Сохранение сбрасывает закэшированное отсутствие URL.
+			assert.NoError(t, cached.Save(ctx, "missing", "https://example.com", "user1", nil))
+			url, err := cached.Get(ctx, "missing")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.com", url)
+		})
+	}
+}
+
+func TestCachedStorage_DeleteInvalidates(t *testing.T) {
+	ctx := context.Background()
+
+	for name, backend := range newBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			store := NewMockStorage()
+			cached := NewCachedStorage(store, backend, time.Minute, time.Minute)
+
+			assert.NoError(t, cached.Save(ctx, "abc", "https://example.com", "user1", nil))
+			_, err := cached.Get(ctx, "abc")
+			assert.NoError(t, err)
+
+			assert.NoError(t, cached.DeleteByUser(ctx, "user1", []string{"abc"}))
+
+			url, err := cached.Get(ctx, "abc")
+			assert.NoError(t, err)
+			assert.Empty(t, url, "deleted URL must not be served from cache")
+			assert.Equal(t, 2, store.gets)
+		})
+	}
+}
+
+func TestCachedStorage_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockStorage()
+	cached := NewCachedStorage(store, NewLRU(100), time.Hour, time.Minute)
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	assert.NoError(t, cached.Save(ctx, "abc", "https://example.com", "user1", &expiresAt))
+
+	url, err := cached.Get(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url)
+
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = cached.Get(ctx, "abc")
+	assert.ErrorIs(t, err, model.ErrURLExpired)
+}
@@ -0,0 +1,325 @@
+// Модуль certgen выпускает ключи и сертификаты X.509 для локального запуска сервиса:
+// корневой CA, серверные сертификаты и клиентские сертификаты для mTLS.
+package certgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Типы ключей.
+const (
+	KeyECDSA   = "ecdsa"
+	KeyEd25519 = "ed25519"
+	KeyRSA     = "rsa"
+)
+
+// Назначения выпускаемых сертификатов.
+const (
+	UsageServer = "server"
+	UsageClient = "client"
+)
+
+// ErrUnknownKeyType возвращается для неподдерживаемого типа ключа.
+var ErrUnknownKeyType = errors.New("unknown key type")
+
+// Request описывает выпускаемый сертификат.
+type Request struct {
+	// CommonName - имя субъекта сертификата.
+	CommonName string
+	// Hosts - DNS-имена и IP-адреса, добавляемые в SAN.
+	Hosts []string
+	// Validity - срок действия сертификата.
+	Validity time.Duration
+	// KeyType - тип ключа: ecdsa, ed25519 или rsa.
+	KeyType string
+	// Usage - назначение листового сертификата: server или client.
+	Usage string
+}
+
+// Certificate - сертификат вместе с закрытым ключом.
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// GenerateKey создает закрытый ключ заданного типа.
+func GenerateKey(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case KeyECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case KeyRSA:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyType, keyType)
+	}
+}
+
+// NewCA выпускает самоподписанный корневой сертификат.
+func NewCA(req Request) (*Certificate, error) {
+	key, err := GenerateKey(req.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(req, key.Public())
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	return create(template, template, key.Public(), key, key)
+}
+
+// Issue выпускает листовой сертификат, подписанный ca.
+//
+// Если ca равен nil, сертификат подписывается собственным ключом (самоподписанный).
+func Issue(req Request, ca *Certificate) (*Certificate, error) {
+	if ca != nil && !ca.Cert.IsCA {
+		return nil, fmt.Errorf("certificate %q is not a CA", ca.Cert.Subject.CommonName)
+	}
+
+	key, err := GenerateKey(req.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(req, key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	switch req.Usage {
+	case UsageServer:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	case UsageClient:
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	default:
+		return nil, fmt.Errorf("unknown certificate usage %q", req.Usage)
+	}
+
+	for _, host := range req.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	if ca == nil {
+		return create(template, template, key.Public(), key, key)
+	}
+
+	return create(template, ca.Cert, key.Public(), ca.Key, key)
+}
+
+func newTemplate(req Request, pub crypto.PublicKey) (*x509.Certificate, error) {
+	if req.Validity <= 0 {
+		return nil, fmt.Errorf("invalid validity: %s", req.Validity)
+	}
+
+	// Серийный номер - случайное 128-битное число, как рекомендует RFC 5280.
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	subjectKeyID, err := keyID(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   req.CommonName,
+			Organization: []string{"url-shortener"},
+		},
+		SubjectKeyId: subjectKeyID,
+		// Небольшой запас на расхождение часов.
+		NotBefore: now.Add(-5 * time.Minute),
+		NotAfter:  now.Add(req.Validity),
+	}, nil
+}
+
+func create(template, parent *x509.Certificate, pub crypto.PublicKey, signer, key crypto.Signer) (*Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{Cert: cert, Key: key}, nil
+}
+
+// keyID вычисляет идентификатор ключа по методу 1 из RFC 5280, раздел 4.2.1.2.
+func keyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum(spki.PublicKey.Bytes)
+	return sum[:], nil
+}
+
+// Write сохраняет сертификат в <dir>/<name>.pem и ключ в <dir>/<name>-key.pem.
+//
+// Ключ записывается в формате PKCS #8 с правами 0600.
+func (c *Certificate) Write(dir, name string) (certFile, keyFile string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(c.Key)
+	if err != nil {
+		return "", "", err
+	}
+
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return "", "", err
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return "", "", err
+	}
+
+	return certFile, keyFile, nil
+}
+
+// Load читает сертификат и ключ в PEM, например CA для выпуска новых сертификатов.
+func Load(certFile, keyFile string) (*Certificate, error) {
+	certs, err := ReadCertificates(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", keyFile)
+	}
+
+	key, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("parse key %s: %w", keyFile, err)
+	}
+
+	return &Certificate{Cert: certs[0], Key: key}, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownKeyType, key)
+	}
+
+	return signer, nil
+}
+
+// ReadCertificates читает все сертификаты из PEM-файла.
+func ReadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate in %s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return certs, nil
+}
+
+// ParseHosts разбирает список хостов, разделенных запятыми.
+func ParseHosts(s string) []string {
+	var hosts []string
+	for _, host := range strings.Split(s, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
@@ -0,0 +1,127 @@
+package certgen
+
+import (
+	"bytes"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssue_ChainVerifies(t *testing.T) {
+	for _, keyType := range []string{KeyECDSA, KeyEd25519, KeyRSA} {
+		t.Run(keyType, func(t *testing.T) {
+			ca, err := NewCA(Request{CommonName: "test CA", Validity: time.Hour, KeyType: keyType})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, ca.Cert.IsCA)
+
+			server, err := Issue(Request{
+				CommonName: "localhost",
+				Hosts:      []string{"localhost", "127.0.0.1"},
+				Validity:   time.Hour,
+				KeyType:    keyType,
+				Usage:      UsageServer,
+			}, ca)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client, err := Issue(Request{CommonName: "client", Validity: time.Hour, KeyType: keyType, Usage: UsageClient}, ca)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.Cert)
+
+			_, err = server.Cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "localhost"})
+			assert.NoError(t, err)
+			assert.Equal(t, "127.0.0.1", server.Cert.IPAddresses[0].String())
+
+			_, err = client.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			assert.NoError(t, err)
+
+			_, err = server.Cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			assert.Error(t, err, "server certificate must not be accepted for client auth")
+
+			assert.NotEqual(t, server.Cert.SerialNumber, client.Cert.SerialNumber)
+		})
+	}
+}
+
+func TestWriteLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs")
+
+	ca, err := NewCA(Request{CommonName: "test CA", Validity: time.Hour, KeyType: KeyEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile, err := ca.Write(dir, "ca")
+	assert.NoError(t, err)
+
+	loaded, err := Load(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, ca.Cert.Raw, loaded.Cert.Raw)
+
+	// Загруженным CA можно подписывать новые сертификаты.
+	server, err := Issue(Request{CommonName: "localhost", Hosts: []string{"localhost"}, Validity: time.Hour, KeyType: KeyECDSA, Usage: UsageServer}, loaded)
+	assert.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	_, err = server.Cert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "localhost"})
+	assert.NoError(t, err)
+}
+
+func TestIssue_Errors(t *testing.T) {
+	leaf, err := Issue(Request{CommonName: "leaf", Validity: time.Hour, KeyType: KeyECDSA, Usage: UsageServer}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  Request
+		ca   *Certificate
+	}{
+		{"Unknown key type", Request{Validity: time.Hour, KeyType: "dsa", Usage: UsageServer}, nil},
+		{"Unknown usage", Request{Validity: time.Hour, KeyType: KeyECDSA, Usage: "email"}, nil},
+		{"Zero validity", Request{KeyType: KeyECDSA, Usage: UsageServer}, nil},
+		{"Signer is not a CA", Request{Validity: time.Hour, KeyType: KeyECDSA, Usage: UsageServer}, leaf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Issue(tt.req, tt.ca)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPrintInfo(t *testing.T) {
+	cert, err := Issue(Request{
+		CommonName: "localhost",
+		Hosts:      []string{"localhost", "::1"},
+		Validity:   time.Hour,
+		KeyType:    KeyECDSA,
+		Usage:      UsageServer,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, PrintInfo(&buf, cert.Cert))
+
+	out := buf.String()
+	assert.Contains(t, out, "CN=localhost")
+	assert.Contains(t, out, "SAN:         localhost, ::1")
+	assert.Contains(t, out, "Key:         ECDSA P-256")
+	assert.Contains(t, out, "Usage:       server auth")
+	assert.Contains(t, out, "(valid)")
+}
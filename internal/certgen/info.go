@@ -0,0 +1,102 @@
+package certgen
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageServerAuth: "server auth",
+	x509.ExtKeyUsageClientAuth: "client auth",
+	x509.ExtKeyUsageAny:        "any",
+}
+
+// PrintInfo выводит основные сведения о сертификате: субъект, издателя,
+// срок действия, SAN, тип ключа, назначение и отпечаток SHA-256.
+func PrintInfo(w io.Writer, cert *x509.Certificate) error {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	var usages []string
+	for _, usage := range cert.ExtKeyUsage {
+		name, ok := extKeyUsageNames[usage]
+		if !ok {
+			name = fmt.Sprintf("unknown(%d)", usage)
+		}
+		usages = append(usages, name)
+	}
+
+	status := "valid"
+	now := time.Now()
+	switch {
+	case now.Before(cert.NotBefore):
+		status = "not yet valid"
+	case now.After(cert.NotAfter):
+		status = "expired"
+	}
+
+	_, err := fmt.Fprintf(w, `Subject:     %s
+Issuer:      %s
+Serial:      %x
+Not before:  %s
+Not after:   %s (%s)
+CA:          %t
+SAN:         %s
+Key:         %s
+Usage:       %s
+SHA-256:     %s
+`,
+		cert.Subject, cert.Issuer, cert.SerialNumber,
+		cert.NotBefore.UTC().Format(time.RFC3339),
+		cert.NotAfter.UTC().Format(time.RFC3339), status,
+		cert.IsCA,
+		valueOrNone(strings.Join(sans, ", ")),
+		keyDescription(cert.PublicKey),
+		valueOrNone(strings.Join(usages, ", ")),
+		formatFingerprint(fingerprint[:]),
+	)
+
+	return err
+}
+
+func keyDescription(pub any) string {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", key.N.BitLen())
+	default:
+		return fmt.Sprintf("%T", pub)
+	}
+}
+
+func formatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
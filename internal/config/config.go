@@ -2,19 +2,32 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/noedaka/go-url-shortener/internal/logger"
 	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
+	"github.com/noedaka/go-url-shortener/internal/service"
+	"github.com/noedaka/go-url-shortener/internal/tracing"
+	"go.uber.org/zap/zapcore"
 )
 
 const UserIDKey model.ContextKey = "user_id"
 
+// schemePattern описывает схему URL по RFC 3986.
+var schemePattern = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
 type Config struct {
 	ServerAddress     string `env:"SERVER_ADDRESS" json:"server_address"`
 	GRPCServerAddress string `env:"GRPC_SERVER_ADDRESS" json:"grpc_server_address"`
@@ -24,13 +37,93 @@ type Config struct {
 	AuditFile         string `env:"AUDIT_FILE" json:"audit_file"`
 	AuditURL          string `env:"AUDIT_URL" json:"audit_url"`
 	EnableHTTPS       bool   `env:"ENABLE_HTTPS" json:"enable_https"`
+	TLSCertFile       string `env:"TLS_CERT_FILE" json:"tls_cert_file"`
+	TLSKeyFile        string `env:"TLS_KEY_FILE" json:"tls_key_file"`
+	TLSClientCAFile   string `env:"TLS_CLIENT_CA_FILE" json:"tls_client_ca_file"`
+	ACMEDomains       string `env:"ACME_DOMAINS" json:"acme_domains"`
+	ACMECacheDir      string `env:"ACME_CACHE_DIR" json:"acme_cache_dir"`
+	ACMEDirectoryURL  string `env:"ACME_DIRECTORY_URL" json:"acme_directory_url"`
+	ACMEEmail         string `env:"ACME_EMAIL" json:"acme_email"`
+	ACMECAFile        string `env:"ACME_CA_FILE" json:"acme_ca_file"`
+	HTTPRedirectAddr  string `env:"HTTP_REDIRECT_ADDRESS" json:"http_redirect_address"`
 	ConfigFile        string `env:"CONFIG"`
 	TrustedSubnet     string `env:"TRUSTED_SUBNET" json:"trusted_subnets"`
 
+	ExpiredPurgeInterval time.Duration `env:"EXPIRED_PURGE_INTERVAL" json:"expired_purge_interval"`
+	FileCompactInterval  time.Duration `env:"FILE_COMPACT_INTERVAL" json:"file_compact_interval"`
+
+	ShortIDStrategy string `env:"SHORT_ID_STRATEGY" json:"short_id_strategy"`
+	ShortIDLength   int    `env:"SHORT_ID_LENGTH" json:"short_id_length"`
+	ShortIDAlphabet string `env:"SHORT_ID_ALPHABET" json:"short_id_alphabet"`
+
+	CacheBackend     string        `env:"CACHE_BACKEND" json:"cache_backend"`
+	CacheSize        int           `env:"CACHE_SIZE" json:"cache_size"`
+	CacheTTL         time.Duration `env:"CACHE_TTL" json:"cache_ttl"`
+	CacheNegativeTTL time.Duration `env:"CACHE_NEGATIVE_TTL" json:"cache_negative_ttl"`
+	RedisURL         string        `env:"REDIS_URL" json:"redis_url"`
+
+	RateLimitStore   string `env:"RATE_LIMIT_STORE" json:"rate_limit_store"`
+	RateLimitKey     string `env:"RATE_LIMIT_KEY" json:"rate_limit_key"`
+	RateLimitDefault string `env:"RATE_LIMIT_DEFAULT" json:"rate_limit_default"`
+	RateLimitShorten string `env:"RATE_LIMIT_SHORTEN" json:"rate_limit_shorten"`
+
+	// Ограничения размера запросов. Нулевое значение заменяется значением по умолчанию.
+	// Значения по умолчанию согласованы: пакет из MaxBatchSize URL длиной MaxURLLength
+	// вместе с JSON-разметкой помещается в MaxBodySize, который ограничивает
+	// и размер сообщения gRPC.
+	MaxBodySize         int64 `env:"MAX_BODY_SIZE" json:"max_body_size"`
+	MaxDecompressedSize int64 `env:"MAX_DECOMPRESSED_SIZE" json:"max_decompressed_size"`
+	MaxBatchSize        int   `env:"MAX_BATCH_SIZE" json:"max_batch_size"`
+	MaxURLLength        int   `env:"MAX_URL_LENGTH" json:"max_url_length"`
+
+	// AllowedSchemes - разрешенные схемы сокращаемых URL через запятую.
+	AllowedSchemes string `env:"ALLOWED_SCHEMES" json:"allowed_schemes"`
+
+	LogLevel  string `env:"LOG_LEVEL" json:"log_level"`
+	LogFormat string `env:"LOG_FORMAT" json:"log_format"`
+
+	TracingExporter    string  `env:"TRACING_EXPORTER" json:"tracing_exporter"`
+	TracingEndpoint    string  `env:"TRACING_ENDPOINT" json:"tracing_endpoint"`
+	TracingInsecure    bool    `env:"TRACING_INSECURE" json:"tracing_insecure"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" json:"tracing_sample_ratio"`
+
+	JWTSecret    string        `env:"JWT_SECRET" json:"jwt_secret"`
+	JWTKeys      string        `env:"JWT_KEYS" json:"jwt_keys"`
+	JWTKeyFile   string        `env:"JWT_KEY_FILE" json:"jwt_key_file"`
+	JWTActiveKID string        `env:"JWT_ACTIVE_KID" json:"jwt_active_kid"`
+	TokenTTL     time.Duration `env:"TOKEN_TTL" json:"token_ttl"`
+
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" json:"shutdown_timeout"`
+	ReadinessTimeout time.Duration `env:"READINESS_TIMEOUT" json:"readiness_timeout"`
+
 	HasDatabase bool
 }
 
+// Типы кэша сокращенных URL. По умолчанию кэш выключен.
+//
+// Кэш в памяти процесса подходит только для одного экземпляра сервиса: удаление
+// URL сбрасывает запись лишь в кэше того экземпляра, который его выполнил, и другие
+// экземпляры отдают удаленный URL до истечения CacheTTL. Несколько экземпляров
+// должны использовать общий кэш Redis.
+const (
+	CacheNone   = "none"
+	CacheMemory = "memory"
+	CacheRedis  = "redis"
+)
+
+// Хранилища лимитов частоты запросов.
+const (
+	RateLimitNone   = "none"
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
 func Init() (*Config, error) {
+	return Load(os.Args[1:])
+}
+
+// Load собирает конфигурацию из переменных окружения, флагов args и файла конфигурации.
+func Load(args []string) (*Config, error) {
 	cfg := &Config{}
 
 	err := env.Parse(cfg)
@@ -39,7 +132,9 @@ func Init() (*Config, error) {
 	}
 
 	cfg.bindFlags()
-	flag.Parse()
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return nil, err
+	}
 
 	if cfg.ConfigFile != "" {
 		configFile, err := cfg.readConfigFile()
@@ -50,6 +145,8 @@ func Init() (*Config, error) {
 		cfg.mergeConfigs(configFile)
 	}
 
+	cfg.setCommonDefaults()
+
 	if cfg.DatabaseDSN != "" {
 		cfg.HasDatabase = true
 		return cfg, nil
@@ -60,6 +157,115 @@ func Init() (*Config, error) {
 	return cfg, nil
 }
 
+// setCommonDefaults задает значения по умолчанию, не зависящие от типа хранилища.
+func (cfg *Config) setCommonDefaults() {
+	if cfg.ExpiredPurgeInterval == 0 {
+		cfg.ExpiredPurgeInterval = time.Minute
+	}
+
+	if cfg.ShortIDStrategy == "" {
+		cfg.ShortIDStrategy = "random"
+	}
+
+	if cfg.ShortIDLength == 0 {
+		cfg.ShortIDLength = 6
+	}
+
+	if cfg.ShortIDAlphabet == "" {
+		cfg.ShortIDAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	}
+
+	if cfg.CacheBackend == "" {
+		cfg.CacheBackend = CacheNone
+	}
+
+	if cfg.CacheSize == 0 {
+		cfg.CacheSize = 10000
+	}
+
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = 5 * time.Minute
+	}
+
+	if cfg.CacheNegativeTTL == 0 {
+		cfg.CacheNegativeTTL = 10 * time.Second
+	}
+
+	if cfg.TokenTTL == 0 {
+		cfg.TokenTTL = 24 * time.Hour
+	}
+
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+
+	if cfg.ReadinessTimeout == 0 {
+		cfg.ReadinessTimeout = 2 * time.Second
+	}
+
+	if cfg.RateLimitStore == "" {
+		cfg.RateLimitStore = RateLimitMemory
+	}
+
+	if cfg.RateLimitKey == "" {
+		cfg.RateLimitKey = ratelimit.KeyUser
+	}
+
+	if cfg.RateLimitDefault == "" {
+		cfg.RateLimitDefault = "100/s:200"
+	}
+
+	if cfg.RateLimitShorten == "" {
+		cfg.RateLimitShorten = "10/s:20"
+	}
+
+	if cfg.MaxBodySize == 0 {
+		cfg.MaxBodySize = 4 << 20
+	}
+
+	if cfg.MaxDecompressedSize == 0 {
+		cfg.MaxDecompressedSize = 10 << 20
+	}
+
+	if cfg.MaxBatchSize == 0 {
+		cfg.MaxBatchSize = 1000
+	}
+
+	if cfg.MaxURLLength == 0 {
+		cfg.MaxURLLength = 2048
+	}
+
+	if cfg.AllowedSchemes == "" {
+		cfg.AllowedSchemes = "http,https"
+	}
+
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = logger.FormatJSON
+	}
+
+	if cfg.TracingExporter == "" {
+		cfg.TracingExporter = tracing.ExporterNone
+	}
+
+	if cfg.TracingSampleRatio == 0 {
+		cfg.TracingSampleRatio = 1
+	}
+
+	if cfg.ACMEDomains != "" {
+		if cfg.ACMECacheDir == "" {
+			cfg.ACMECacheDir = "acme-cache"
+		}
+
+		if cfg.HTTPRedirectAddr == "" {
+			cfg.HTTPRedirectAddr = ":80"
+		}
+	}
+}
+
 func (cfg *Config) setDefaults() {
 	if cfg.ServerAddress == "" {
 		cfg.ServerAddress = "localhost:8080"
@@ -72,6 +278,10 @@ func (cfg *Config) setDefaults() {
 		cfg.FileStoragePath = "urls.json"
 	}
 
+	if cfg.FileCompactInterval == 0 {
+		cfg.FileCompactInterval = time.Hour
+	}
+
 	if cfg.GRPCServerAddress == "" {
 		cfg.GRPCServerAddress = ":3020"
 	}
@@ -86,8 +296,49 @@ func (cfg *Config) bindFlags() {
 	flag.StringVar(&cfg.AuditFile, "audit-file", cfg.AuditFile, "Audit file")
 	flag.StringVar(&cfg.AuditURL, "audit-url", cfg.AuditURL, "Audit URL")
 	flag.BoolVar(&cfg.EnableHTTPS, "s", cfg.EnableHTTPS, "Enable HTTPS")
+	flag.StringVar(&cfg.TLSCertFile, "tls-cert", cfg.TLSCertFile, "TLS certificate file for HTTP and gRPC")
+	flag.StringVar(&cfg.TLSKeyFile, "tls-key", cfg.TLSKeyFile, "TLS private key file for HTTP and gRPC")
+	flag.StringVar(&cfg.TLSClientCAFile, "tls-client-ca", cfg.TLSClientCAFile, "CA file to verify client certificates for internal endpoints")
+	flag.StringVar(&cfg.ACMEDomains, "acme-domains", cfg.ACMEDomains, "Comma-separated domains to obtain ACME certificates for")
+	flag.StringVar(&cfg.ACMECacheDir, "acme-cache-dir", cfg.ACMECacheDir, "Directory to cache ACME account and certificates")
+	flag.StringVar(&cfg.ACMEDirectoryURL, "acme-directory", cfg.ACMEDirectoryURL, "ACME directory URL (Let's Encrypt by default)")
+	flag.StringVar(&cfg.ACMEEmail, "acme-email", cfg.ACMEEmail, "Contact email for the ACME account")
+	flag.StringVar(&cfg.ACMECAFile, "acme-ca", cfg.ACMECAFile, "CA file to trust when connecting to the ACME server")
+	flag.StringVar(&cfg.HTTPRedirectAddr, "http-redirect", cfg.HTTPRedirectAddr, "Plain HTTP address redirecting to HTTPS and serving ACME challenges")
 	flag.StringVar(&cfg.ConfigFile, "c", cfg.ConfigFile, "Config file path")
 	flag.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "Trusted subnet")
+	flag.DurationVar(&cfg.ExpiredPurgeInterval, "purge-interval", cfg.ExpiredPurgeInterval, "Expired URLs purge interval")
+	flag.DurationVar(&cfg.FileCompactInterval, "compact-interval", cfg.FileCompactInterval, "File storage compaction interval")
+	flag.StringVar(&cfg.ShortIDStrategy, "id-strategy", cfg.ShortIDStrategy, "Short ID generation strategy (random, counter, hash)")
+	flag.IntVar(&cfg.ShortIDLength, "id-length", cfg.ShortIDLength, "Short ID length")
+	flag.StringVar(&cfg.ShortIDAlphabet, "id-alphabet", cfg.ShortIDAlphabet, "Short ID alphabet")
+	flag.StringVar(&cfg.CacheBackend, "cache", cfg.CacheBackend, "URL cache backend (none, memory for a single instance, redis)")
+	flag.IntVar(&cfg.CacheSize, "cache-size", cfg.CacheSize, "In-memory URL cache capacity")
+	flag.DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "URL cache entry TTL")
+	flag.DurationVar(&cfg.CacheNegativeTTL, "cache-negative-ttl", cfg.CacheNegativeTTL, "TTL of cached missing URL lookups")
+	flag.StringVar(&cfg.RedisURL, "redis-url", cfg.RedisURL, "Redis URL for the redis cache backend and rate limit store")
+	flag.StringVar(&cfg.RateLimitStore, "rate-limit-store", cfg.RateLimitStore, "Rate limit store (none, memory, redis)")
+	flag.StringVar(&cfg.RateLimitKey, "rate-limit-key", cfg.RateLimitKey, "Rate limit client key (user, ip)")
+	flag.StringVar(&cfg.RateLimitDefault, "rate-limit", cfg.RateLimitDefault, "Rate limit for all requests, e.g. 100/s:200 (0 disables)")
+	flag.StringVar(&cfg.RateLimitShorten, "rate-limit-shorten", cfg.RateLimitShorten, "Rate limit for shortening requests, e.g. 10/s:20 (0 disables)")
+	flag.Int64Var(&cfg.MaxBodySize, "max-body-size", cfg.MaxBodySize, "Maximum request body and gRPC message size in bytes")
+	flag.Int64Var(&cfg.MaxDecompressedSize, "max-decompressed-size", cfg.MaxDecompressedSize, "Maximum gzip request body size after decompression in bytes")
+	flag.IntVar(&cfg.MaxBatchSize, "max-batch-size", cfg.MaxBatchSize, "Maximum number of URLs in a batch request")
+	flag.IntVar(&cfg.MaxURLLength, "max-url-length", cfg.MaxURLLength, "Maximum length of a URL to shorten in bytes")
+	flag.StringVar(&cfg.AllowedSchemes, "allowed-schemes", cfg.AllowedSchemes, "Comma-separated URL schemes allowed for shortening")
+	flag.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level (debug, info, warn, error)")
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log format (json, console)")
+	flag.StringVar(&cfg.TracingExporter, "tracing", cfg.TracingExporter, "Trace exporter (none, otlp, stdout)")
+	flag.StringVar(&cfg.TracingEndpoint, "tracing-endpoint", cfg.TracingEndpoint, "OTLP/gRPC collector address, e.g. localhost:4317")
+	flag.BoolVar(&cfg.TracingInsecure, "tracing-insecure", cfg.TracingInsecure, "Connect to the OTLP collector without TLS")
+	flag.Float64Var(&cfg.TracingSampleRatio, "tracing-sample-ratio", cfg.TracingSampleRatio, "Fraction of new traces to record (0..1]")
+	flag.StringVar(&cfg.JWTSecret, "jwt-secret", cfg.JWTSecret, "JWT signing secret")
+	flag.StringVar(&cfg.JWTKeys, "jwt-keys", cfg.JWTKeys, "JWT signing keys as kid:secret,kid:secret")
+	flag.StringVar(&cfg.JWTKeyFile, "jwt-key-file", cfg.JWTKeyFile, "JSON file with JWT signing keys")
+	flag.StringVar(&cfg.JWTActiveKID, "jwt-active-kid", cfg.JWTActiveKID, "ID of the key used to sign new tokens")
+	flag.DurationVar(&cfg.TokenTTL, "token-ttl", cfg.TokenTTL, "Auth token lifetime")
+	flag.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "Time to drain in-flight requests on shutdown")
+	flag.DurationVar(&cfg.ReadinessTimeout, "readiness-timeout", cfg.ReadinessTimeout, "Timeout of each readiness check")
 }
 
 func (cfg *Config) readConfigFile() (*Config, error) {
@@ -126,6 +377,28 @@ func (cfg *Config) mergeConfigs(override *Config) {
 	}
 }
 
+// Limits возвращает ограничения размера запросов.
+func (cfg *Config) Limits() limits.Limits {
+	return limits.Limits{
+		MaxBodySize:         cfg.MaxBodySize,
+		MaxDecompressedSize: cfg.MaxDecompressedSize,
+		MaxBatchSize:        cfg.MaxBatchSize,
+		MaxURLLength:        cfg.MaxURLLength,
+	}
+}
+
+// Schemes возвращает разрешенные схемы сокращаемых URL в нижнем регистре.
+func (cfg *Config) Schemes() []string {
+	var schemes []string
+	for _, scheme := range strings.Split(cfg.AllowedSchemes, ",") {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return schemes
+}
+
 func (cfg *Config) ValidateConfig() error {
 	_, _, err := net.SplitHostPort(cfg.ServerAddress)
 	if err != nil {
@@ -137,5 +410,117 @@ func (cfg *Config) ValidateConfig() error {
 		return fmt.Errorf("invalid base URL: %s", u)
 	}
 
+	if cfg.ShortIDLength <= 0 {
+		return fmt.Errorf("invalid short id length: %d", cfg.ShortIDLength)
+	}
+
+	if err := service.ValidateAlphabet(cfg.ShortIDAlphabet); err != nil {
+		return fmt.Errorf("invalid short id alphabet: %w", err)
+	}
+
+	if cfg.ACMEDomains != "" {
+		if !cfg.EnableHTTPS {
+			return errors.New("ACME requires HTTPS to be enabled")
+		}
+		if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+			return errors.New("ACME and TLS certificate files are mutually exclusive")
+		}
+		if cfg.TLSClientCAFile != "" {
+			return errors.New("client certificate verification is not supported in ACME mode")
+		}
+	} else if cfg.EnableHTTPS && (cfg.TLSCertFile == "" || cfg.TLSKeyFile == "") {
+		return errors.New("TLS certificate and key files are required when HTTPS is enabled")
+	}
+
+	if cfg.TLSClientCAFile != "" && !cfg.EnableHTTPS {
+		return errors.New("client certificate verification requires HTTPS to be enabled")
+	}
+
+	if cfg.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown timeout: %s", cfg.ShutdownTimeout)
+	}
+
+	if cfg.ReadinessTimeout < 0 {
+		return fmt.Errorf("invalid readiness timeout: %s", cfg.ReadinessTimeout)
+	}
+
+	switch cfg.RateLimitStore {
+	case RateLimitNone, RateLimitMemory:
+	case RateLimitRedis:
+		if cfg.RedisURL == "" {
+			return errors.New("redis URL is required for the redis rate limit store")
+		}
+	default:
+		return fmt.Errorf("unknown rate limit store: %s", cfg.RateLimitStore)
+	}
+
+	if cfg.RateLimitKey != ratelimit.KeyUser && cfg.RateLimitKey != ratelimit.KeyIP {
+		return fmt.Errorf("unknown rate limit key: %s", cfg.RateLimitKey)
+	}
+
+	for _, limit := range []string{cfg.RateLimitDefault, cfg.RateLimitShorten} {
+		if _, _, err := ratelimit.ParseLimit(limit); err != nil {
+			return err
+		}
+	}
+
+	if cfg.MaxBodySize < 0 {
+		return fmt.Errorf("invalid max body size: %d", cfg.MaxBodySize)
+	}
+
+	if cfg.MaxDecompressedSize < 0 {
+		return fmt.Errorf("invalid max decompressed size: %d", cfg.MaxDecompressedSize)
+	}
+
+	if cfg.MaxBatchSize < 0 {
+		return fmt.Errorf("invalid max batch size: %d", cfg.MaxBatchSize)
+	}
+
+	if cfg.MaxURLLength < 0 {
+		return fmt.Errorf("invalid max url length: %d", cfg.MaxURLLength)
+	}
+
+	schemes := cfg.Schemes()
+	if len(schemes) == 0 {
+		return errors.New("at least one allowed url scheme is required")
+	}
+	for _, scheme := range schemes {
+		if !schemePattern.MatchString(scheme) {
+			return fmt.Errorf("invalid url scheme: %s", scheme)
+		}
+	}
+
+	if _, err := zapcore.ParseLevel(cfg.LogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %s", cfg.LogLevel)
+	}
+
+	if cfg.LogFormat != logger.FormatJSON && cfg.LogFormat != logger.FormatConsole {
+		return fmt.Errorf("unknown log format: %s", cfg.LogFormat)
+	}
+
+	switch cfg.TracingExporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		return fmt.Errorf("unknown tracing exporter: %s", cfg.TracingExporter)
+	}
+
+	if cfg.TracingSampleRatio <= 0 || cfg.TracingSampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio: %g", cfg.TracingSampleRatio)
+	}
+
+	switch cfg.CacheBackend {
+	case CacheNone:
+	case CacheMemory:
+		if cfg.CacheSize <= 0 {
+			return fmt.Errorf("invalid cache size: %d", cfg.CacheSize)
+		}
+	case CacheRedis:
+		if cfg.RedisURL == "" {
+			return errors.New("redis URL is required for the redis cache backend")
+		}
+	default:
+		return fmt.Errorf("unknown cache backend: %s", cfg.CacheBackend)
+	}
+
 	return nil
 }
@@ -1,28 +1,20 @@
 package dbc
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
 
-func InitDatabase(db *sql.DB) error {
-	_, err := db.Exec(`
-        CREATE TABLE IF NOT EXISTS urls (
-			id SERIAL PRIMARY KEY,
-			short_url TEXT NOT NULL,
-			original_url TEXT NOT NULL,
-			user_id TEXT NOT NULL,
-			is_deleted BOOLEAN DEFAULT FALSE
-        )
-    `)
-	if err != nil {
-		return err
-	}
+	"github.com/noedaka/go-url-shortener/internal/migrator"
+	"github.com/noedaka/go-url-shortener/migrations"
+)
 
-	_, err = db.Exec(`
-		CREATE UNIQUE INDEX IF NOT EXISTS idx_og_url
-		ON urls (original_url)
-	`)
+// InitDatabase применяет к базе данных все непримененные миграции из каталога migrations.
+func InitDatabase(ctx context.Context, db *sql.DB) error {
+	m, err := migrator.New(db, migrations.FS)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = m.Up(ctx)
+	return err
 }
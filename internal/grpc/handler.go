@@ -2,28 +2,57 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/noedaka/go-url-shortener/api/proto"
+	"github.com/noedaka/go-url-shortener/internal/auth"
 	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/netutil"
 	"github.com/noedaka/go-url-shortener/internal/service"
+	"github.com/noedaka/go-url-shortener/internal/tlsutil"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Handler обрабатывает gRPC запросы
 type handler struct {
 	proto.UnimplementedShortenerServiceServer
-	service service.ShortenerService
-	baseURL string
+	service       service.ShortenerService
+	baseURL       string
+	trustedSubnet string
+	// requireClientCert включает проверку клиентского сертификата для внутренних методов.
+	requireClientCert bool
+	// limits ограничивает число URL в потоке ShortenStream.
+	limits limits.Limits
+	tokens *auth.TokenService
 }
 
 // NewHandler создает новый gRPC хендлер
-func newHandler(service service.ShortenerService, baseURL string) *handler {
+func newHandler(service service.ShortenerService, cfg config.Config, tokens *auth.TokenService) *handler {
 	return &handler{
-		service: service,
-		baseURL: baseURL,
+		service:           service,
+		baseURL:           cfg.BaseURL,
+		trustedSubnet:     cfg.TrustedSubnet,
+		requireClientCert: cfg.TLSClientCAFile != "",
+		limits:            cfg.Limits(),
+		tokens:            tokens,
 	}
 }
 
@@ -34,9 +63,18 @@ func (h *handler) ShortenURL(ctx context.Context, req *proto.URLShortenRequest)
 		return nil, status.Error(codes.Unauthenticated, "unauthorized")
 	}
 
-	shortID, err := h.service.ShortenURL(ctx, req.GetUrl(), userID)
+	opts := model.ShortenOptions{
+		Alias:      req.GetAlias(),
+		TTLSeconds: req.GetTtlSeconds(),
+	}
+	if req.HasExpiresAt() {
+		expiresAt := req.GetExpiresAt().AsTime()
+		opts.ExpiresAt = &expiresAt
+	}
+
+	shortID, err := h.service.ShortenURLWithOptions(ctx, req.GetUrl(), userID, opts)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot shorten URL: %v", err)
+		return nil, h.shortenError(err)
 	}
 
 	shortURL := fmt.Sprintf("%s/%s", h.baseURL, shortID)
@@ -50,6 +88,14 @@ func (h *handler) ShortenURL(ctx context.Context, req *proto.URLShortenRequest)
 // ExpandURL обрабатывает запрос на получение оригинального URL
 func (h *handler) ExpandURL(ctx context.Context, req *proto.URLExpandRequest) (*proto.URLExpandResponse, error) {
 	originalURL, err := h.service.GetURL(ctx, req.GetId())
+	if errors.Is(err, model.ErrURLExpired) {
+		return nil, status.Error(codes.NotFound, "URL has expired")
+	}
+
+	if errors.Is(err, model.ErrShortURLNotFound) {
+		return nil, status.Error(codes.NotFound, "URL not found")
+	}
+
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "cannot get URL: %v", err)
 	}
@@ -93,6 +139,340 @@ func (h *handler) ListUserURLs(ctx context.Context, req *emptypb.Empty) (*proto.
 	return &response, nil
 }
 
+// ShortenBatch обрабатывает запрос на сокращение нескольких URL
+func (h *handler) ShortenBatch(ctx context.Context, req *proto.ShortenBatchRequest) (*proto.ShortenBatchResponse, error) {
+	userID, ok := getUserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	if len(req.GetUrls()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "empty batch")
+	}
+
+	batchRequest := make([]model.BatchRequest, 0, len(req.GetUrls()))
+	for _, url := range req.GetUrls() {
+		item := model.BatchRequest{
+			CorrelationID: url.GetCorrelationId(),
+			URL:           url.GetOriginalUrl(),
+			Alias:         url.GetAlias(),
+			TTLSeconds:    url.GetTtlSeconds(),
+		}
+		if url.HasExpiresAt() {
+			expiresAt := url.GetExpiresAt().AsTime()
+			item.ExpiresAt = &expiresAt
+		}
+
+		batchRequest = append(batchRequest, item)
+	}
+
+	batchResponse, err := h.service.ShortenMultipleURLS(ctx, batchRequest, userID)
+	if err != nil {
+		return nil, h.shortenError(err)
+	}
+
+	URLs := make([]*proto.BatchShortURL, 0, len(batchResponse))
+	for _, item := range batchResponse {
+		var URL proto.BatchShortURL
+		URL.SetCorrelationId(item.CorrelationID)
+		URL.SetShortUrl(item.ShortURL)
+
+		URLs = append(URLs, &URL)
+	}
+
+	var response proto.ShortenBatchResponse
+	response.SetUrls(URLs)
+
+	return &response, nil
+}
+
+// DeleteUserURLs обрабатывает запрос на удаление сокращенных URL текущего пользователя
+func (h *handler) DeleteUserURLs(ctx context.Context, req *proto.DeleteUserURLsRequest) (*emptypb.Empty, error) {
+	userID, ok := getUserIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	if err := h.service.DeleteShortURLSByUser(ctx, userID, req.GetShortUrls()); err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot delete URLs: %v", err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetStats возвращает количество сокращенных URL и пользователей.
+//
+// Доступен только клиентам, адрес соединения которых входит в доверенную подсеть.
+// При включенном mTLS клиент также должен предъявить доверенный сертификат.
+func (h *handler) GetStats(ctx context.Context, req *emptypb.Empty) (*proto.StatsResponse, error) {
+	if h.trustedSubnet == "" {
+		return nil, status.Error(codes.Unavailable, "trusted subnet is not configured")
+	}
+
+	if ip := peerIP(ctx); ip == nil || !netutil.InSubnet(ip, h.trustedSubnet) {
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	if h.requireClientCert && !tlsutil.HasVerifiedClientCert(peerTLSState(ctx)) {
+		return nil, status.Error(codes.PermissionDenied, "client certificate required")
+	}
+
+	stats, err := h.service.GetStats(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot get stats: %v", err)
+	}
+
+	var response proto.StatsResponse
+	response.SetUrls(int64(stats.URLs))
+	response.SetUsers(int64(stats.Users))
+
+	return &response, nil
+}
+
+// Ping проверяет доступность хранилища.
+func (h *handler) Ping(ctx context.Context, req *emptypb.Empty) (*emptypb.Empty, error) {
+	pingCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if err := h.service.Ping(pingCtx); err != nil {
+		// Ping доступен без аутентификации, поэтому причина только пишется в лог.
+		logger.FromContext(ctx).Warn("storage ping failed", zap.Error(err))
+		return nil, status.Error(codes.Unavailable, "storage is unavailable")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ShortenStream сокращает URL по мере их получения из потока клиента.
+//
+// Ошибка сокращения отдельного URL не прерывает поток: она возвращается
+// в результате для соответствующего correlation_id. Следующее сообщение
+// читается только после обработки предыдущего, поэтому быстрый клиент
+// упирается в управление потоком gRPC.
+func (h *handler) ShortenStream(stream grpc.ClientStreamingServer[proto.BatchURL, proto.ShortenStreamResponse]) error {
+	ctx := stream.Context()
+
+	userID, ok := getUserIDFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	var results []*proto.ShortenResult
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := h.limits.CheckBatch(len(results) + 1); err != nil {
+			return h.shortenError(err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		results = append(results, h.shortenStreamItem(ctx, userID, item))
+	}
+
+	var response proto.ShortenStreamResponse
+	response.SetResults(results)
+
+	return stream.SendAndClose(&response)
+}
+
+func (h *handler) shortenStreamItem(ctx context.Context, userID string, item *proto.BatchURL) *proto.ShortenResult {
+	opts := model.ShortenOptions{
+		Alias:      item.GetAlias(),
+		TTLSeconds: item.GetTtlSeconds(),
+	}
+	if item.HasExpiresAt() {
+		expiresAt := item.GetExpiresAt().AsTime()
+		opts.ExpiresAt = &expiresAt
+	}
+
+	var result proto.ShortenResult
+	result.SetCorrelationId(item.GetCorrelationId())
+
+	shortID, err := h.service.ShortenURLWithOptions(ctx, item.GetOriginalUrl(), userID, opts)
+	if err != nil {
+		st := status.Convert(h.shortenError(err))
+		result.SetCode(int32(st.Code()))
+		result.SetError(st.Message())
+
+		var uniqueErr *model.UniqueViolationError
+		if errors.As(err, &uniqueErr) {
+			result.SetShortUrl(fmt.Sprintf("%s/%s", h.baseURL, uniqueErr.ShortID))
+		}
+
+		return &result
+	}
+
+	result.SetShortUrl(fmt.Sprintf("%s/%s", h.baseURL, shortID))
+
+	return &result
+}
+
+// StreamUserURLs передает URL текущего пользователя по одному.
+//
+// Send блокируется, пока клиент не прочитает предыдущие сообщения,
+// поэтому чтение из хранилища идет со скоростью клиента.
+func (h *handler) StreamUserURLs(req *emptypb.Empty, stream grpc.ServerStreamingServer[proto.URLData]) error {
+	ctx := stream.Context()
+
+	userID, ok := getUserIDFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	err := h.service.StreamURLsByUser(ctx, userID, func(pair model.URLPair) error {
+		var URL proto.URLData
+		URL.SetShortUrl(pair.ShortURL)
+		URL.SetOriginalUrl(pair.OriginalURL)
+
+		return stream.Send(&URL)
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "cannot stream URLs: %v", err)
+	}
+
+	return nil
+}
+
+// IssueToken выдает токен пользователю из контекста или новому пользователю.
+//
+// Не требует авторизации: клиент без токена получает новый идентификатор пользователя.
+func (h *handler) IssueToken(ctx context.Context, req *proto.IssueTokenRequest) (*proto.TokenResponse, error) {
+	userID, ok := getUserIDFromContext(ctx)
+	if !ok {
+		userID = uuid.New().String()
+	}
+
+	token, expiresAt, err := h.tokens.Issue(userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot issue token: %v", err)
+	}
+
+	return newTokenResponse(token, userID, expiresAt), nil
+}
+
+// RefreshToken выдает новый токен пользователю из переданного токена
+func (h *handler) RefreshToken(ctx context.Context, req *proto.RefreshTokenRequest) (*proto.TokenResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	token, userID, expiresAt, err := h.tokens.Refresh(req.GetToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "cannot refresh token: %v", err)
+	}
+
+	return newTokenResponse(token, userID, expiresAt), nil
+}
+
+func newTokenResponse(token, userID string, expiresAt time.Time) *proto.TokenResponse {
+	var response proto.TokenResponse
+	response.SetToken(token)
+	response.SetUserId(userID)
+	response.SetExpiresAt(timestamppb.New(expiresAt))
+
+	return &response
+}
+
+// shortenError преобразует ошибку сокращения URL в статус gRPC так же, как HTTP-хендлеры.
+//
+// Если URL уже был сокращен, в детали ошибки добавляется существующий сокращенный URL.
+func (h *handler) shortenError(err error) error {
+	if limitErr, ok := limits.As(err); ok {
+		return limitError(limitErr)
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidURL),
+		errors.Is(err, service.ErrInvalidAlias),
+		errors.Is(err, service.ErrReservedAlias),
+		errors.Is(err, service.ErrInvalidExpiry):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, model.ErrShortURLExists):
+		return status.Error(codes.AlreadyExists, "alias already in use")
+	}
+
+	var uniqueErr *model.UniqueViolationError
+	if errors.As(err, &uniqueErr) {
+		shortURL := fmt.Sprintf("%s/%s", h.baseURL, uniqueErr.ShortID)
+
+		st := status.New(codes.AlreadyExists, "url already shortened")
+		withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason:   "URL_ALREADY_SHORTENED",
+			Domain:   "shortener",
+			Metadata: map[string]string{"short_url": shortURL},
+		})
+		if detailsErr != nil {
+			return st.Err()
+		}
+		return withDetails.Err()
+	}
+
+	return status.Errorf(codes.Internal, "cannot shorten URL: %v", err)
+}
+
+// limitError возвращает InvalidArgument с причиной и значением нарушенного ограничения в ErrorInfo.
+func limitError(err *limits.Error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   err.Reason,
+		Domain:   "shortener",
+		Metadata: map[string]string{"limit": strconv.FormatInt(err.Limit, 10)},
+	})
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// peerIP возвращает IP-адрес клиента из адреса соединения.
+func peerIP(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	return net.ParseIP(host)
+}
+
+// peerTLSState возвращает состояние TLS-соединения клиента или nil для соединения без TLS.
+func peerTLSState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+
+	return &info.State
+}
+
 func getUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(config.UserIDKey).(string)
 	return userID, ok
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/noedaka/go-url-shortener/api/proto"
+	"github.com/noedaka/go-url-shortener/internal/health"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthServices - имена сервисов в grpc.health.v1. Пустое имя означает сервер целиком.
+var healthServices = []string{"", proto.ShortenerService_ServiceDesc.ServiceName}
+
+// healthService реализует grpc.health.v1.
+//
+// Статус SERVING выставляется при запуске сервера и снимается в начале остановки.
+// Check дополнительно выполняет проверки готовности, поэтому при недоступном
+// хранилище возвращает NOT_SERVING. Watch и List сообщают только состояние сервера.
+type healthService struct {
+	*grpchealth.Server
+	readiness *health.Checker
+}
+
+func newHealthService(readiness *health.Checker) *healthService {
+	s := &healthService{Server: grpchealth.NewServer(), readiness: readiness}
+	s.setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return s
+}
+
+// Check возвращает статус сервиса с учетом проверок готовности.
+func (s *healthService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	resp, err := s.Server.Check(ctx, req)
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING || s.readiness == nil {
+		return resp, err
+	}
+
+	if report := s.readiness.Check(ctx); !report.Ready() {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+
+	return resp, nil
+}
+
+func (s *healthService) setStatus(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range healthServices {
+		s.SetServingStatus(service, status)
+	}
+}
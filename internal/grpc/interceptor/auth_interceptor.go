@@ -4,32 +4,80 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
-	"github.com/golang-jwt/jwt/v4"
+	"github.com/noedaka/go-url-shortener/internal/auth"
 	"github.com/noedaka/go-url-shortener/internal/config"
-	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
-const (
-	secretKey = "supersecretkey"
-)
+// AuthInterceptor проверяет токен из метаданных authorization
+// и кладет идентификатор пользователя в контекст запроса.
+//
+// Методы из publicMethods доступны без токена: если токен передан и действителен,
+// пользователь все равно попадает в контекст.
+func AuthInterceptor(tokens *auth.TokenService, publicMethods ...string) grpc.UnaryServerInterceptor {
+	authorize := newAuthorizer(tokens, publicMethods)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor выполняет ту же проверку, что и AuthInterceptor, для потоковых методов.
+func StreamAuthInterceptor(tokens *auth.TokenService, publicMethods ...string) grpc.StreamServerInterceptor {
+	authorize := newAuthorizer(tokens, publicMethods)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// contextStream подменяет контекст потока контекстом, дополненным перехватчиком.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-func AuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	userID, err := authenticateUser(ctx)
-	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "authentication required: %v", err)
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func newAuthorizer(tokens *auth.TokenService, publicMethods []string) func(ctx context.Context, method string) (context.Context, error) {
+	public := make(map[string]struct{}, len(publicMethods))
+	for _, method := range publicMethods {
+		public[method] = struct{}{}
 	}
 
-	ctx = context.WithValue(ctx, config.UserIDKey, userID)
-	return handler(ctx, req)
+	return func(ctx context.Context, method string) (context.Context, error) {
+		userID, err := authenticateUser(ctx, tokens)
+		if err != nil {
+			if _, ok := public[method]; ok {
+				return ctx, nil
+			}
+			return nil, status.Errorf(codes.Unauthenticated, "authentication required: %v", err)
+		}
+
+		logger.SetAccessUserID(ctx, userID)
+
+		return context.WithValue(ctx, config.UserIDKey, userID), nil
+	}
 }
 
-func authenticateUser(ctx context.Context) (string, error) {
+func authenticateUser(ctx context.Context, tokens *auth.TokenService) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return "", fmt.Errorf("metadata not found")
@@ -49,25 +97,5 @@ func authenticateUser(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("empty authorization token")
 	}
 
-	claims := &model.Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secretKey), nil
-	})
-
-	if err != nil {
-		return "", err
-	}
-
-	if !token.Valid {
-		return "", fmt.Errorf("token is not valid")
-	}
-
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
-		return "", fmt.Errorf("token expired")
-	}
-
-	return claims.UserID, nil
+	return tokens.Parse(tokenStr)
 }
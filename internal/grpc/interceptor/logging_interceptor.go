@@ -0,0 +1,100 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata - ключ метаданных с идентификатором запроса.
+const RequestIDMetadata = "x-request-id"
+
+// LoggingInterceptor пишет одну строку журнала доступа на вызов.
+//
+// Идентификатор запроса берется из метаданных x-request-id или создается заново
+// и возвращается клиенту в заголовке ответа. Должен стоять сразу после
+// TracingInterceptor, чтобы строка журнала содержала идентификатор трассы.
+func LoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = startAccess(ctx)
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// StreamLoggingInterceptor пишет строку журнала доступа при закрытии потока.
+func StreamLoggingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := startAccess(ss.Context())
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func startAccess(ctx context.Context) context.Context {
+	var candidate string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadata); len(values) > 0 {
+			candidate = values[0]
+		}
+	}
+
+	requestID := logger.RequestIDOrNew(candidate)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID)); err != nil {
+		logger.Log.Debug("failed to set request id header", zap.Error(err))
+	}
+
+	ctx = logger.ContextWithRequestID(ctx, requestID)
+
+	return logger.ContextWithAccess(ctx)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := zapcore.InfoLevel
+	if isServerError(code) {
+		level = zapcore.ErrorLevel
+	}
+
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("code", code.String()),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("user_id", logger.AccessUserID(ctx)),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields = append(fields, zap.String("peer", p.Addr.String()))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	logger.FromContext(ctx).Log(level, "grpc request", fields...)
+}
+
+// isServerError сообщает, означает ли код ошибку сервера, а не клиента.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsInterceptor считает вызовы методов и время их обработки.
+//
+// Должен стоять первым, чтобы учитывались и вызовы, отклоненные
+// аутентификацией или лимитами.
+func MetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeCall(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor считает потоковые вызовы и время от открытия до закрытия потока.
+func StreamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeCall(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func observeCall(method string, start time.Time, err error) {
+	metrics.GRPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GRPCDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
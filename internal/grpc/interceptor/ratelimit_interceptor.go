@@ -0,0 +1,103 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimits - лимиты gRPC-методов.
+type RateLimits struct {
+	// Default применяется ко всем методам. nil отключает общий лимит.
+	Default *ratelimit.Limiter
+	// Methods - дополнительные лимиты отдельных методов по полному имени.
+	Methods map[string]*ratelimit.Limiter
+	// KeyBy - способ определения клиента: ratelimit.KeyUser или ratelimit.KeyIP.
+	KeyBy string
+}
+
+// RateLimitInterceptor ограничивает частоту вызовов методов.
+//
+// Должен стоять после AuthInterceptor, чтобы лимит по пользователю применялся
+// к пользователю из токена. При превышении лимита возвращает ResourceExhausted
+// с RetryInfo и заголовком retry-after.
+func RateLimitInterceptor(limits RateLimits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := limits.check(ctx, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		}); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor выполняет ту же проверку, что и RateLimitInterceptor,
+// при открытии потока.
+func StreamRateLimitInterceptor(limits RateLimits) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := limits.check(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func (l RateLimits) check(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	userID, _ := ctx.Value(config.UserIDKey).(string)
+	key := ratelimit.ClientKey(l.KeyBy, userID, peerIP(ctx))
+
+	for _, limiter := range []*ratelimit.Limiter{l.Default, l.Methods[method]} {
+		if limiter == nil {
+			continue
+		}
+
+		res, err := limiter.Allow(ctx, key)
+		if err != nil {
+			logger.FromContext(ctx).Warn("rate limit check failed, allowing request", zap.Error(err))
+			continue
+		}
+
+		if !res.Allowed {
+			retryAfter := ratelimit.RetryAfterSeconds(res.RetryAfter)
+			_ = setHeader(metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+
+			st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(res.RetryAfter)})
+			if err != nil {
+				return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+			}
+			return st.Err()
+		}
+	}
+
+	return nil
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
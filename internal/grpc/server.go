@@ -1,43 +1,203 @@
 package grpc
 
 import (
-	"log"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"net"
+	"sync/atomic"
 
 	"github.com/noedaka/go-url-shortener/api/proto"
+	"github.com/noedaka/go-url-shortener/internal/auth"
 	"github.com/noedaka/go-url-shortener/internal/config"
 	"github.com/noedaka/go-url-shortener/internal/grpc/interceptor"
+	"github.com/noedaka/go-url-shortener/internal/health"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
 	"github.com/noedaka/go-url-shortener/internal/service"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// GRPCServer управляет жизненным циклом gRPC-сервера сервиса сокращения URL.
 type GRPCServer struct {
 	cfg     config.Config
 	service service.ShortenerService
+	tokens  *auth.TokenService
+	tls     *tls.Config
+	limits  *interceptor.RateLimits
+	server  *grpc.Server
+
+	readiness *health.Checker
+	health    *healthService
+	serving   atomic.Bool
+}
+
+// Option настраивает GRPCServer.
+type Option func(*GRPCServer)
+
+// WithTLS включает TLS с переданной конфигурацией.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *GRPCServer) {
+		s.tls = cfg
+	}
+}
+
+// WithRateLimits ограничивает частоту вызовов: defaultLimiter применяется ко всем методам,
+// shortenLimiter - дополнительно к методам сокращения URL. nil отключает соответствующий лимит.
+func WithRateLimits(defaultLimiter, shortenLimiter *ratelimit.Limiter, keyBy string) Option {
+	return func(s *GRPCServer) {
+		if defaultLimiter == nil && shortenLimiter == nil {
+			return
+		}
+
+		limits := &interceptor.RateLimits{Default: defaultLimiter, KeyBy: keyBy}
+
+		if shortenLimiter != nil {
+			limits.Methods = map[string]*ratelimit.Limiter{
+				proto.ShortenerService_ShortenURL_FullMethodName:    shortenLimiter,
+				proto.ShortenerService_ShortenBatch_FullMethodName:  shortenLimiter,
+				proto.ShortenerService_ShortenStream_FullMethodName: shortenLimiter,
+			}
+		}
+
+		s.limits = limits
+	}
+}
+
+// WithReadiness добавляет проверки готовности к ответу Check сервиса grpc.health.v1.
+func WithReadiness(checker *health.Checker) Option {
+	return func(s *GRPCServer) {
+		s.readiness = checker
+	}
 }
 
-func NewGRPCServer(cfg config.Config, service service.ShortenerService) *GRPCServer {
-	return &GRPCServer{
+// NewGRPCServer создает gRPC-сервер. Для запуска нужно вызвать Start.
+func NewGRPCServer(cfg config.Config, service service.ShortenerService, tokens *auth.TokenService, opts ...Option) *GRPCServer {
+	s := &GRPCServer{
 		cfg:     cfg,
 		service: service,
+		tokens:  tokens,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	s.server = s.newServer()
+
+	return s
 }
 
-func (s *GRPCServer) StartServer() {
+// Start слушает адрес из конфигурации и обслуживает запросы.
+//
+// Блокируется до остановки сервера. После GracefulStop возвращает nil.
+func (s *GRPCServer) Start() error {
 	listen, err := net.Listen("tcp", s.cfg.GRPCServerAddress)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		return fmt.Errorf("grpc listen on %s: %w", s.cfg.GRPCServerAddress, err)
 	}
 
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(interceptor.AuthInterceptor),
-	)
+	return s.Serve(listen)
+}
 
-	handler := newHandler(s.service, s.cfg.BaseURL)
+// Serve обслуживает запросы на переданном слушателе. После GracefulStop возвращает nil.
+func (s *GRPCServer) Serve(listen net.Listener) error {
+	s.serving.Store(true)
+	s.health.setStatus(healthpb.HealthCheckResponse_SERVING)
+	defer s.serving.Store(false)
 
-	proto.RegisterShortenerServiceServer(grpcServer, handler)
+	if err := s.server.Serve(listen); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
 
-	if err := grpcServer.Serve(listen); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
+	return nil
+}
+
+// Serving сообщает, обслуживает ли сервер запросы. Становится false в начале остановки.
+func (s *GRPCServer) Serving() bool {
+	return s.serving.Load()
+}
+
+// GracefulStop перестает принимать новые запросы и дожидается завершения текущих.
+//
+// Если ctx отменяется раньше, оставшиеся соединения закрываются принудительно
+// и возвращается ошибка контекста.
+func (s *GRPCServer) GracefulStop(ctx context.Context) error {
+	// Клиенты grpc.health.v1 узнают об остановке до закрытия соединений.
+	s.serving.Store(false)
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// newServer создает gRPC-сервер с зарегистрированным обработчиком и перехватчиками.
+func (s *GRPCServer) newServer() *grpc.Server {
+	publicMethods := []string{
+		proto.ShortenerService_IssueToken_FullMethodName,
+		proto.ShortenerService_RefreshToken_FullMethodName,
+		proto.ShortenerService_GetStats_FullMethodName,
+		proto.ShortenerService_Ping_FullMethodName,
+		healthpb.Health_Check_FullMethodName,
+		healthpb.Health_List_FullMethodName,
+		healthpb.Health_Watch_FullMethodName,
 	}
+
+	unary := []grpc.UnaryServerInterceptor{
+		interceptor.TracingInterceptor(),
+		interceptor.LoggingInterceptor(),
+		interceptor.MetricsInterceptor(),
+		interceptor.AuthInterceptor(s.tokens, publicMethods...),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		interceptor.StreamTracingInterceptor(),
+		interceptor.StreamLoggingInterceptor(),
+		interceptor.StreamMetricsInterceptor(),
+		interceptor.StreamAuthInterceptor(s.tokens, publicMethods...),
+	}
+
+	// Лимиты проверяются после аутентификации, чтобы был известен пользователь.
+	if s.limits != nil {
+		unary = append(unary, interceptor.RateLimitInterceptor(*s.limits))
+		stream = append(stream, interceptor.StreamRateLimitInterceptor(*s.limits))
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+
+	// Сообщения больше ограничения отклоняются библиотекой с кодом ResourceExhausted.
+	if s.cfg.MaxBodySize > 0 {
+		serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(int(s.cfg.MaxBodySize)))
+	}
+
+	if s.tls != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
+
+	handler := newHandler(s.service, s.cfg, s.tokens)
+
+	proto.RegisterShortenerServiceServer(grpcServer, handler)
+
+	s.health = newHealthService(s.readiness)
+	healthpb.RegisterHealthServer(grpcServer, s.health)
+
+	return grpcServer
 }
@@ -12,9 +12,13 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/noedaka/go-url-shortener/internal/analytics"
 	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/noedaka/go-url-shortener/internal/metrics"
 	"github.com/noedaka/go-url-shortener/internal/middleware"
 	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/netutil"
 	"github.com/noedaka/go-url-shortener/internal/service"
 )
 
@@ -22,11 +26,29 @@ import (
 type Handler struct {
 	service service.ShortenerService
 	db      *sql.DB
+	// trustedSubnet - подсеть прокси, которым доверяется заголовок X-Real-IP.
+	trustedSubnet string
+}
+
+// Option настраивает Handler.
+type Option func(*Handler)
+
+// WithTrustedSubnet задает подсеть доверенных прокси. Адрес клиента из заголовка
+// X-Real-IP учитывается, только если запрос пришел из этой подсети.
+func WithTrustedSubnet(subnet string) Option {
+	return func(h *Handler) {
+		h.trustedSubnet = subnet
+	}
 }
 
 // NewHandler создает новый экземпляр Handler.
-func NewHandler(service service.ShortenerService, db *sql.DB) *Handler {
-	return &Handler{service: service, db: db}
+func NewHandler(service service.ShortenerService, db *sql.DB, opts ...Option) *Handler {
+	h := &Handler{service: service, db: db}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // ShortenURLHandler создает короткий URL из переданного URL.
@@ -37,7 +59,7 @@ func NewHandler(service service.ShortenerService, db *sql.DB) *Handler {
 func (h *Handler) ShortenURLHandler(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "cannot read body", http.StatusBadRequest)
+		writeReadError(w, err, "cannot read body")
 		return
 	}
 
@@ -59,7 +81,7 @@ func (h *Handler) ShortenURLHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	middleware.LogAuditEvent(r.Context(), "shorten", originalURL)
+	h.logShortenEvent(r.Context(), originalURL)
 
 	shortURL := h.service.BaseURL + "/" + shortID
 
@@ -77,7 +99,7 @@ func (h *Handler) APIShortenerHandler(w http.ResponseWriter, r *http.Request) {
 	var req model.Request
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "cannot decode request JSON body", http.StatusBadRequest)
+		writeReadError(w, err, "cannot decode request JSON body")
 		return
 	}
 
@@ -87,7 +109,12 @@ func (h *Handler) APIShortenerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shortID, err := h.service.ShortenURL(r.Context(), req.URL, userID)
+	opts := model.ShortenOptions{
+		Alias:      req.Alias,
+		ExpiresAt:  req.ExpiresAt,
+		TTLSeconds: req.TTLSeconds,
+	}
+	shortID, err := h.service.ShortenURLWithOptions(r.Context(), req.URL, userID, opts)
 	if err != nil {
 		if h.handleShortenError(w, err, "application/json") {
 			return
@@ -96,7 +123,7 @@ func (h *Handler) APIShortenerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	middleware.LogAuditEvent(r.Context(), "shorten", req.URL)
+	h.logShortenEvent(r.Context(), req.URL)
 
 	shortURL := h.service.BaseURL + "/" + shortID
 
@@ -154,22 +181,69 @@ func (h *Handler) ShortIDHandler(w http.ResponseWriter, r *http.Request) {
 	shortID := chi.URLParam(r, "id")
 
 	URL, err := h.service.GetURL(r.Context(), shortID)
+	if errors.Is(err, model.ErrURLExpired) {
+		metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
 	if err != nil {
+		metrics.Redirects.WithLabelValues(metrics.RedirectMissing).Inc()
 		http.Error(w, "cannot get url from id", http.StatusBadRequest)
 		return
 	}
 
 	if URL == "" {
+		metrics.Redirects.WithLabelValues(metrics.RedirectGone).Inc()
 		w.WriteHeader(http.StatusGone)
 		return
 	}
 
+	metrics.Redirects.WithLabelValues(metrics.RedirectFound).Inc()
+
 	middleware.LogAuditEvent(r.Context(), "follow", URL)
+	h.service.RecordClick(analytics.NewClick(shortID, r.Referer(), r.UserAgent(), h.clientIP(r), time.Now()))
 
 	w.Header().Set("Location", URL)
 	w.WriteHeader(http.StatusTemporaryRedirect)
 }
 
+// APIURLStatsHandler возвращает статистику переходов по сокращенному URL текущего пользователя.
+//
+// Возвращает общее число переходов, число уникальных посетителей
+// и почасовую и посуточную разбивку в application/json.
+//
+// GET /api/user/urls/{id}/stats
+func (h *Handler) APIURLStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	stats, err := h.service.GetClickStats(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrURLNotFound):
+			http.Error(w, "url not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrAnalyticsDisabled):
+			http.Error(w, "click analytics disabled", http.StatusNotImplemented)
+		default:
+			http.Error(w, "cannot get url stats", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(stats); err != nil {
+		http.Error(w, "error encoding response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // ShortenBatchHandler создает короткие URL каждому переданному URL.
 //
 // Принимает application/json/ возвращает batchResponse в application/json.
@@ -179,7 +253,7 @@ func (h *Handler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request) {
 	var batchRequest []model.BatchRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&batchRequest); err != nil {
-		http.Error(w, "cannot decode request JSON body", http.StatusBadRequest)
+		writeReadError(w, err, "cannot decode request JSON body")
 		return
 	}
 
@@ -196,6 +270,9 @@ func (h *Handler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request) {
 
 	batchResponse, err := h.service.ShortenMultipleURLS(r.Context(), batchRequest, userID)
 	if err != nil {
+		if handleOptionsError(w, err) {
+			return
+		}
 		http.Error(w, "cannot shorten multiple urls", http.StatusInternalServerError)
 		return
 	}
@@ -225,7 +302,7 @@ func (h *Handler) APIDeleteShortURLSHandler(w http.ResponseWriter, r *http.Reque
 	var shortURLS []string
 
 	if err := json.NewDecoder(r.Body).Decode(&shortURLS); err != nil {
-		http.Error(w, "cannot decode request JSON body", http.StatusBadRequest)
+		writeReadError(w, err, "cannot decode request JSON body")
 		return
 	}
 
@@ -237,55 +314,84 @@ func (h *Handler) APIDeleteShortURLSHandler(w http.ResponseWriter, r *http.Reque
 }
 
 // PingDBHandler пингует БД.
+//
+// Без настроенной БД отвечает 500, как и при недоступной БД.
+// Для проверок готовности сервиса используется /readyz.
 func (h *Handler) PingDBHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	if h.db == nil {
+		http.Error(w, "database is not configured", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 1*time.Second)
 	defer cancel()
 	if err := h.db.PingContext(ctx); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		http.Error(w, "database is unavailable", http.StatusInternalServerError)
+		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *Handler) StatsHandler(trustedSubnet string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		ipStr := r.Header.Get("X-Real-IP")
-		ip := net.ParseIP(ipStr)
-
-		if ip == nil || !isIPInSubnet(ip, trustedSubnet) {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
-
-		stats, err := h.service.GetStats(r.Context())
-		if err != nil {
-			http.Error(w, "Error getting stats", http.StatusInternalServerError)
-		}
+// StatsHandler возвращает количество сокращенных URL и пользователей в сервисе.
+//
+// GET /api/internal/stats
+func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetStats(r.Context())
+	if err != nil {
+		http.Error(w, "Error getting stats", http.StatusInternalServerError)
+		return
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-		enc := json.NewEncoder(w)
-		if err := enc.Encode(stats); err != nil {
-			http.Error(w, "error encoding response", http.StatusInternalServerError)
-			return
-		}
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(stats); err != nil {
+		http.Error(w, "error encoding response", http.StatusInternalServerError)
+		return
 	}
 }
 
-func isIPInSubnet(ip net.IP, subnet string) bool {
-	if checkIP := net.ParseIP(subnet); checkIP != nil {
-		return ip.Equal(checkIP)
+// logShortenEvent пишет в аудит сокращенный URL в канонической форме, в которой он
+// сохранен, чтобы журнал аудита и хранилище называли одну ссылку одинаково.
+func (h *Handler) logShortenEvent(ctx context.Context, rawURL string) {
+	canonicalURL, err := h.service.CanonicalURL(rawURL)
+	if err != nil {
+		// Сюда попадают только уже сохраненные URL, поэтому ошибки не ожидается.
+		canonicalURL = rawURL
 	}
 
-	_, network, err := net.ParseCIDR(subnet)
+	middleware.LogAuditEvent(ctx, "shorten", canonicalURL)
+}
+
+// clientIP возвращает адрес клиента для статистики переходов.
+//
+// Заголовок X-Real-IP может прислать любой клиент, поэтому он учитывается,
+// только если соединение установлено из доверенной подсети. Иначе используется адрес соединения.
+func (h *Handler) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		return false
+		host = r.RemoteAddr
+	}
+
+	if h.trustedSubnet == "" {
+		return host
 	}
 
-	return network.Contains(ip)
+	if ip := net.ParseIP(host); ip != nil && netutil.InSubnet(ip, h.trustedSubnet) {
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return realIP
+		}
+	}
+
+	return host
 }
 
 func (h *Handler) handleShortenError(w http.ResponseWriter, err error, contentType string) (handled bool) {
+	if handleOptionsError(w, err) {
+		return true
+	}
+
 	var uniqueErr *model.UniqueViolationError
 	if errors.As(err, &uniqueErr) {
 		shortURL := h.service.BaseURL + "/" + uniqueErr.ShortID
@@ -306,6 +412,38 @@ func (h *Handler) handleShortenError(w http.ResponseWriter, err error, contentTy
 	return false
 }
 
+func handleOptionsError(w http.ResponseWriter, err error) (handled bool) {
+	if limitErr, ok := limits.As(err); ok {
+		limits.WriteHTTPError(w, limitErr)
+		return true
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidURL),
+		errors.Is(err, service.ErrInvalidAlias),
+		errors.Is(err, service.ErrReservedAlias),
+		errors.Is(err, service.ErrInvalidExpiry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return true
+	case errors.Is(err, model.ErrShortURLExists):
+		http.Error(w, "alias already in use", http.StatusConflict)
+		return true
+	}
+
+	return false
+}
+
+// writeReadError отвечает на ошибку чтения тела запроса: при превышении
+// ограничения размера - описанием ограничения, иначе 400 с сообщением msg.
+func writeReadError(w http.ResponseWriter, err error, msg string) {
+	if limitErr, ok := limits.As(err); ok {
+		limits.WriteHTTPError(w, limitErr)
+		return
+	}
+
+	http.Error(w, msg, http.StatusBadRequest)
+}
+
 func getUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(config.UserIDKey).(string)
 	return userID, ok
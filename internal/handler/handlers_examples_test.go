@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/noedaka/go-url-shortener/internal/config"
@@ -26,7 +27,7 @@ func NewExampleMockStorage() *ExampleMockStorage {
 	}
 }
 
-func (m *ExampleMockStorage) Save(ctx context.Context, shortURL, originalURL, userID string) error {
+func (m *ExampleMockStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
 	m.urls[shortURL] = originalURL
 	if _, exists := m.users[userID]; !exists {
 		m.users[userID] = make(map[string]string)
@@ -47,6 +48,14 @@ func (m *ExampleMockStorage) GetStats(ctx context.Context) (*model.Stats, error)
 	return nil, nil
 }
 
+func (m *ExampleMockStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *ExampleMockStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (m *ExampleMockStorage) GetByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
 	userURLs, exists := m.users[userID]
 	if !exists {
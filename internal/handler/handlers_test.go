@@ -7,10 +7,15 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/noedaka/go-url-shortener/internal/audit"
 	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/noedaka/go-url-shortener/internal/middleware"
 	"github.com/noedaka/go-url-shortener/internal/model"
 	"github.com/noedaka/go-url-shortener/internal/service"
 	"github.com/stretchr/testify/assert"
@@ -33,7 +38,7 @@ func NewMockStorage() *MockStorage {
 	}
 }
 
-func (m *MockStorage) Save(ctx context.Context, shortURL, originalURL, userID string) error {
+func (m *MockStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -118,7 +123,18 @@ func (m *MockStorage) DeleteByUser(ctx context.Context, userID string, shortURLs
 }
 
 func (m *MockStorage) GetStats(ctx context.Context) (*model.Stats, error) {
-	return nil, nil
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &model.Stats{URLs: len(m.urls), Users: len(m.users)}, nil
+}
+
+func (m *MockStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStorage) Ping(ctx context.Context) error {
+	return nil
 }
 
 func (m *MockStorage) ResetDeletedArgs() {
@@ -172,7 +188,28 @@ func TestHandler_ShortenURLHandler(t *testing.T) {
 			body:   "",
 			userID: "test-user",
 			want: want{
-				statusCode: http.StatusCreated,
+				statusCode: http.StatusBadRequest,
+				contains:   "invalid url",
+			},
+		},
+		{
+			name:   "POST javascript URI",
+			method: http.MethodPost,
+			body:   "javascript:alert(1)",
+			userID: "test-user",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				contains:   "not allowed",
+			},
+		},
+		{
+			name:   "POST relative path",
+			method: http.MethodPost,
+			body:   "/some/path",
+			userID: "test-user",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				contains:   "absolute",
 			},
 		},
 		{
@@ -270,7 +307,7 @@ func TestHandler_ShortIdHandler(t *testing.T) {
 
 func TestHandler_APIShortenerHandler(t *testing.T) {
 	mockStorage := NewMockStorage()
-	svc := service.NewShortenerService(mockStorage, "http://localhost:8080")
+	svc := service.NewShortenerService(mockStorage, "http://localhost:8080", service.WithReservedAliases("api"))
 	h := NewHandler(*svc, nil)
 
 	r := chi.NewRouter()
@@ -310,6 +347,47 @@ func TestHandler_APIShortenerHandler(t *testing.T) {
 				statusCode: http.StatusBadRequest,
 			},
 		},
+		{
+			name:   "POST with alias",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.com/q4", "alias": "q4-report"}`,
+			userID: "test-user",
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: "application/json",
+				contains:    `"result":"http://localhost:8080/q4-report"`,
+			},
+		},
+		{
+			name:   "POST with reserved alias",
+			method: http.MethodPost,
+			body:   `{"url": "https://example.com", "alias": "api"}`,
+			userID: "test-user",
+			want: want{
+				statusCode: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "POST javascript URI",
+			method: http.MethodPost,
+			body:   `{"url": "javascript:alert(1)"}`,
+			userID: "test-user",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				contains:   "not allowed",
+			},
+		},
+		{
+			name:   "POST URL is normalized",
+			method: http.MethodPost,
+			body:   `{"url": "HTTPS://Example.COM:443/a%7eb", "alias": "normalized"}`,
+			userID: "test-user",
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: "application/json",
+				contains:    `"result":"http://localhost:8080/normalized"`,
+			},
+		},
 		{
 			name:   "Wrong method",
 			method: http.MethodPut,
@@ -414,6 +492,16 @@ func TestHandler_ShortenBatchHandler(t *testing.T) {
 				contains:   "cannot decode request JSON body",
 			},
 		},
+		{
+			name:   "POST batch with invalid URL",
+			method: http.MethodPost,
+			body:   `[{"correlation_id": "ok", "original_url": "https://example.com/1"}, {"correlation_id": "bad", "original_url": "ftp://example.com/file"}]`,
+			userID: "test-user",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				contains:   "invalid url",
+			},
+		},
 		{
 			name:   "Wrong method",
 			method: http.MethodGet,
@@ -605,3 +693,223 @@ func TestHandler_APIDeleteShortURLSHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_StatsHandler(t *testing.T) {
+	mockStorage := NewMockStorage()
+	svc := service.NewShortenerService(mockStorage, "http://localhost:8080")
+	h := NewHandler(*svc, nil)
+
+	r := chi.NewRouter()
+	r.With(middleware.TrustedSubnetMiddleware("192.168.0.0/24")).Get("/api/internal/stats", h.StatsHandler)
+
+	tests := []struct {
+		name         string
+		realIP       string
+		prepare      func(s *MockStorage)
+		wantStatus   int
+		wantContains string
+	}{
+		{
+			name:   "Trusted IP",
+			realIP: "192.168.0.10",
+			prepare: func(s *MockStorage) {
+				s.AddURLForUser("short1", "https://example.com/1", "test-user")
+			},
+			wantStatus:   http.StatusOK,
+			wantContains: `{"urls":1,"users":1}`,
+		},
+		{
+			name:       "Untrusted IP",
+			realIP:     "10.0.0.1",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "Storage error",
+			realIP: "192.168.0.10",
+			prepare: func(s *MockStorage) {
+				s.SetError(errors.New("storage error"))
+			},
+			wantStatus:   http.StatusInternalServerError,
+			wantContains: "Error getting stats",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage.SetError(nil)
+
+			if tt.prepare != nil {
+				tt.prepare(mockStorage)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.Header.Set("X-Real-IP", tt.realIP)
+
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantContains != "" {
+				assert.Contains(t, rr.Body.String(), tt.wantContains)
+				assert.NotContains(t, rr.Body.String(), "null")
+			}
+		})
+	}
+}
+
+func TestHandler_Limits(t *testing.T) {
+	svc := service.NewShortenerService(NewMockStorage(), "http://localhost:8080",
+		service.WithLimits(limits.Limits{MaxBatchSize: 2, MaxURLLength: 32}))
+	h := NewHandler(*svc, nil)
+
+	r := chi.NewRouter()
+	r.Use(middleware.BodyLimitMiddleware(256))
+	r.Post("/", h.ShortenURLHandler)
+	r.Post("/api/shorten", h.APIShortenerHandler)
+	r.Post("/api/shorten/batch", h.ShortenBatchHandler)
+
+	longURL := "https://example.com/" + strings.Repeat("a", 32)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantReason string
+	}{
+		{"Text URL too long", "/", longURL, http.StatusBadRequest, limits.ReasonURLTooLong},
+		{"JSON URL too long", "/api/shorten", `{"url":"` + longURL + `"}`, http.StatusBadRequest, limits.ReasonURLTooLong},
+		{"Text body too large", "/", strings.Repeat("a", 512), http.StatusRequestEntityTooLarge, limits.ReasonBodyTooLarge},
+		{"JSON body too large", "/api/shorten", `{"url":"` + strings.Repeat("a", 512) + `"}`, http.StatusRequestEntityTooLarge, limits.ReasonBodyTooLarge},
+		{
+			name:       "Batch too large",
+			path:       "/api/shorten/batch",
+			body:       `[{"correlation_id":"1","original_url":"https://a.com"},{"correlation_id":"2","original_url":"https://b.com"},{"correlation_id":"3","original_url":"https://c.com"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantReason: limits.ReasonBatchTooLarge,
+		},
+		{
+			name:       "Batch within limit",
+			path:       "/api/shorten/batch",
+			body:       `[{"correlation_id":"1","original_url":"https://a.com"},{"correlation_id":"2","original_url":"https://b.com"}]`,
+			wantStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req = req.WithContext(withUserID(req.Context(), "test-user"))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantReason != "" {
+				assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+				var resp limits.ErrorResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantReason, resp.Error)
+				assert.NotZero(t, resp.Limit)
+			}
+		})
+	}
+}
+
+func TestHandler_PingDBHandler_WithoutDatabase(t *testing.T) {
+	svc := service.NewShortenerService(NewMockStorage(), "http://localhost:8080")
+	h := NewHandler(*svc, nil)
+
+	w := httptest.NewRecorder()
+	h.PingDBHandler(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "database is not configured")
+}
+
+func TestHandler_ClientIP(t *testing.T) {
+	tests := []struct {
+		name          string
+		trustedSubnet string
+		remoteAddr    string
+		realIP        string
+		want          string
+	}{
+		{"No trusted subnet", "", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"Untrusted peer", "10.0.0.0/8", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"Trusted proxy", "10.0.0.0/8", "10.0.0.2:5000", "198.51.100.1", "198.51.100.1"},
+		{"Trusted proxy without header", "10.0.0.0/8", "10.0.0.2:5000", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(service.ShortenerService{}, nil, WithTrustedSubnet(tt.trustedSubnet))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			assert.Equal(t, tt.want, h.clientIP(req))
+		})
+	}
+}
+
+// auditRecorder передает полученные события аудита в канал.
+type auditRecorder chan model.AuditEvent
+
+func (a auditRecorder) Notify(event model.AuditEvent) error {
+	a <- event
+	return nil
+}
+
+func (a auditRecorder) Close() error {
+	return nil
+}
+
+func TestHandler_AuditLogsCanonicalURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		contentType string
+	}{
+		{"Plain text", "/", "HTTPS://Example.COM:443/%7Euser", "text/plain"},
+		{"JSON", "/api/shorten", `{"url":"HTTPS://Example.COM:443/%7Euser"}`, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := service.NewShortenerService(NewMockStorage(), "http://localhost:8080")
+			h := NewHandler(*svc, nil)
+
+			events := make(auditRecorder, 1)
+			manager := audit.NewAuditManager()
+			manager.RegisterObserver(events)
+
+			r := chi.NewRouter()
+			r.Use(middleware.AuditMiddleware(manager))
+			r.Post("/", h.ShortenURLHandler)
+			r.Post("/api/shorten", h.APIShortenerHandler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(context.WithValue(req.Context(), config.UserIDKey, "test-user"))
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+			assert.Equal(t, http.StatusCreated, rr.Code)
+
+			select {
+			case event := <-events:
+				assert.Equal(t, "https://example.com/~user", event.URL, "audit must record the stored URL")
+			case <-time.After(time.Second):
+				t.Fatal("audit event was not delivered")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Модуль health проверяет готовность сервиса к обработке запросов.
+//
+// Живость процесса (liveness) не зависит от внешних систем, а готовность
+// (readiness) определяется набором проверок зависимостей: хранилища,
+// получателей аудита и состояния gRPC-сервера.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Состояния проверок.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc проверяет зависимость. Ошибка означает, что зависимость недоступна.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult - результат одной проверки.
+type CheckResult struct {
+	Status string `json:"status"`
+	// Error содержит текст ошибки проверки. Он может раскрывать адреса и пути
+	// зависимостей, поэтому не отдается клиенту, а только пишется в лог.
+	Error string `json:"-"`
+	// DurationMS - время проверки в миллисекундах.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// Report - результат всех проверок готовности.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready сообщает, прошли ли все проверки.
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// reportTTL - время, в течение которого Check возвращает готовый отчет,
+// не обращаясь к зависимостям повторно.
+const reportTTL = time.Second
+
+// Checker выполняет проверки готовности.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+
+	// mu защищает последний отчет и не дает выполнять проверки параллельно:
+	// частые запросы /readyz получают один и тот же отчет.
+	mu        sync.Mutex
+	report    Report
+	checkedAt time.Time
+}
+
+// NewChecker создает Checker, ограничивающий каждую проверку временем timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку с именем name. Вызывается до начала проверок.
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Check параллельно выполняет все проверки и собирает отчет.
+// Сервис готов, если ни одна проверка не вернула ошибку.
+//
+// Отчет, собранный менее reportTTL назад, возвращается без повторных проверок,
+// чтобы запросы к /readyz не нагружали зависимости.
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < reportTTL {
+		return c.report
+	}
+
+	c.report = c.check(ctx)
+	c.checkedAt = time.Now()
+
+	return c.report
+}
+
+func (c *Checker) check(ctx context.Context) Report {
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, ch.fn)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(c.checks))}
+	for i, ch := range c.checks {
+		report.Checks[ch.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, fn CheckFunc) CheckResult {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	result := CheckResult{Status: StatusUp, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// LivenessHandler отвечает 200, пока процесс способен обрабатывать HTTP-запросы.
+//
+// GET /healthz
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, struct {
+		Status string `json:"status"`
+	}{Status: StatusUp})
+}
+
+// ReadinessHandler выполняет проверки checker и возвращает отчет:
+// 200, если сервис готов, и 503, если хотя бы одна проверка не прошла.
+//
+// Клиент получает только состояние каждой проверки, ошибки пишутся в лог.
+//
+// GET /readyz
+func ReadinessHandler(checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
+
+		code := http.StatusOK
+		if !report.Ready() {
+			code = http.StatusServiceUnavailable
+			for name, result := range report.Checks {
+				if result.Error != "" {
+					logger.FromContext(r.Context()).Warn("readiness check failed",
+						zap.String("check", name),
+						zap.String("error", result.Error))
+				}
+			}
+		}
+
+		writeJSON(w, code, report)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
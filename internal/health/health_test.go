@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	failed := func(context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		wantStatus int
+		wantReport map[string]string
+	}{
+		{
+			name:       "All checks pass",
+			checks:     map[string]CheckFunc{"storage": ok, "grpc": ok},
+			wantStatus: http.StatusOK,
+			wantReport: map[string]string{"storage": StatusUp, "grpc": StatusUp},
+		},
+		{
+			name:       "Failed check",
+			checks:     map[string]CheckFunc{"storage": failed, "grpc": ok},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: map[string]string{"storage": StatusDown, "grpc": StatusUp},
+		},
+		{
+			name:       "Check exceeds timeout",
+			checks:     map[string]CheckFunc{"audit": slow},
+			wantStatus: http.StatusServiceUnavailable,
+			wantReport: map[string]string{"audit": StatusDown},
+		},
+		{
+			name:       "No checks",
+			checks:     map[string]CheckFunc{},
+			wantStatus: http.StatusOK,
+			wantReport: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(50 * time.Millisecond)
+			for name, fn := range tt.checks {
+				checker.Add(name, fn)
+			}
+
+			w := httptest.NewRecorder()
+			ReadinessHandler(checker)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			assert.NotContains(t, w.Body.String(), "connection refused", "check errors must not be exposed")
+
+			var report Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string, len(report.Checks))
+			for name, result := range report.Checks {
+				got[name] = result.Status
+			}
+			assert.Equal(t, tt.wantReport, got)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, report.Ready())
+		})
+	}
+}
+
+func TestChecker_ReusesRecentReport(t *testing.T) {
+	var calls atomic.Int32
+	checker := NewChecker(time.Second)
+	checker.Add("storage", func(context.Context) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	})
+
+	for i := 0; i < 5; i++ {
+		report := checker.Check(context.Background())
+		assert.False(t, report.Ready())
+		assert.Equal(t, "connection refused", report.Checks["storage"].Error)
+	}
+
+	assert.Equal(t, int32(1), calls.Load(), "checks must not run again within reportTTL")
+}
+
+func TestLivenessHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	LivenessHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"up"}`, w.Body.String())
+}
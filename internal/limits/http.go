@@ -0,0 +1,26 @@
+package limits
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorResponse - тело ответа HTTP при нарушении ограничения.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Limit   int64  `json:"limit"`
+}
+
+// WriteHTTPError отвечает клиенту кодом и описанием нарушенного ограничения в application/json.
+func WriteHTTPError(w http.ResponseWriter, err *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(err.HTTPStatus())
+
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   err.Reason,
+		Message: err.Error(),
+		Limit:   err.Limit,
+	})
+}
@@ -0,0 +1,143 @@
+// Модуль limits задает ограничения размера запросов к сервису.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Причины нарушения ограничений. Передаются клиенту в поле error ответа HTTP
+// и в ErrorInfo.Reason ответа gRPC.
+const (
+	ReasonBodyTooLarge         = "BODY_TOO_LARGE"
+	ReasonDecompressedTooLarge = "DECOMPRESSED_BODY_TOO_LARGE"
+	ReasonBatchTooLarge        = "BATCH_TOO_LARGE"
+	ReasonURLTooLong           = "URL_TOO_LONG"
+)
+
+// Limits - ограничения размера запросов.
+//
+// Сервис получает Limits из config.Config, где все поля положительны. Поля
+// нулевого значения Limits{} не проверяются: оно используется, когда сервис
+// создается без конфигурации, например в тестах.
+type Limits struct {
+	// MaxBodySize - максимальный размер тела запроса HTTP и сообщения gRPC в байтах.
+	MaxBodySize int64
+	// MaxDecompressedSize - максимальный размер тела запроса после распаковки gzip.
+	MaxDecompressedSize int64
+	// MaxBatchSize - максимальное число URL в пакетном запросе.
+	MaxBatchSize int
+	// MaxURLLength - максимальная длина сокращаемого URL в байтах.
+	MaxURLLength int
+}
+
+// Error описывает нарушенное ограничение.
+type Error struct {
+	Reason string
+	Limit  int64
+}
+
+func (e *Error) Error() string {
+	switch e.Reason {
+	case ReasonBodyTooLarge:
+		return fmt.Sprintf("request body is larger than %d bytes", e.Limit)
+	case ReasonDecompressedTooLarge:
+		return fmt.Sprintf("decompressed request body is larger than %d bytes", e.Limit)
+	case ReasonBatchTooLarge:
+		return fmt.Sprintf("batch contains more than %d urls", e.Limit)
+	case ReasonURLTooLong:
+		return fmt.Sprintf("url is longer than %d bytes", e.Limit)
+	}
+
+	return fmt.Sprintf("limit %s exceeded: %d", e.Reason, e.Limit)
+}
+
+// HTTPStatus возвращает код ответа HTTP для нарушенного ограничения:
+// 413 для размера тела и 400 для содержимого запроса.
+func (e *Error) HTTPStatus() int {
+	switch e.Reason {
+	case ReasonBodyTooLarge, ReasonDecompressedTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// CheckURL проверяет длину сокращаемого URL.
+func (l Limits) CheckURL(rawURL string) error {
+	if l.MaxURLLength > 0 && len(rawURL) > l.MaxURLLength {
+		return &Error{Reason: ReasonURLTooLong, Limit: int64(l.MaxURLLength)}
+	}
+
+	return nil
+}
+
+// CheckBatch проверяет число URL в пакетном запросе.
+func (l Limits) CheckBatch(n int) error {
+	if l.MaxBatchSize > 0 && n > l.MaxBatchSize {
+		return &Error{Reason: ReasonBatchTooLarge, Limit: int64(l.MaxBatchSize)}
+	}
+
+	return nil
+}
+
+// As извлекает нарушенное ограничение из ошибки.
+//
+// Ошибка http.MaxBytesReader преобразуется в ReasonBodyTooLarge,
+// поэтому ошибки чтения тела запроса можно передавать как есть.
+func As(err error) (*Error, bool) {
+	var limitErr *Error
+	if errors.As(err, &limitErr) {
+		return limitErr, true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &Error{Reason: ReasonBodyTooLarge, Limit: maxBytesErr.Limit}, true
+	}
+
+	return nil, false
+}
+
+// NewDecompressedReader ограничивает r n байтами.
+//
+// В отличие от io.LimitReader, при превышении возвращает ошибку
+// ReasonDecompressedTooLarge, а не io.EOF, чтобы обрезанное тело
+// не было принято за корректное. При n <= 0, что возможно только без
+// конфигурации, возвращает r без ограничения.
+func NewDecompressedReader(r io.Reader, n int64) io.Reader {
+	if n <= 0 {
+		return r
+	}
+
+	return &limitedReader{r: r, n: n, limit: n}
+}
+
+type limitedReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Проверяем, что за пределом ограничения данных действительно нет.
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			return 0, &Error{Reason: ReasonDecompressedTooLarge, Limit: l.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,108 @@
+package limits
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_Check(t *testing.T) {
+	l := Limits{MaxBatchSize: 2, MaxURLLength: 10}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+	}{
+		{"URL within limit", l.CheckURL("http://a.b"), ""},
+		{"URL too long", l.CheckURL("http://a.bc"), ReasonURLTooLong},
+		{"Batch within limit", l.CheckBatch(2), ""},
+		{"Batch too large", l.CheckBatch(3), ReasonBatchTooLarge},
+		{"Zero limits are disabled", Limits{}.CheckURL(strings.Repeat("a", 1<<16)), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantReason == "" {
+				assert.NoError(t, tt.err)
+				return
+			}
+
+			limitErr, ok := As(tt.err)
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.wantReason, limitErr.Reason)
+				assert.Equal(t, http.StatusBadRequest, limitErr.HTTPStatus())
+			}
+		})
+	}
+}
+
+func TestAs(t *testing.T) {
+	w := httptest.NewRecorder()
+	body := http.MaxBytesReader(w, io.NopCloser(strings.NewReader("0123456789")), 4)
+	_, err := io.ReadAll(body)
+
+	limitErr, ok := As(fmt.Errorf("read body: %w", err))
+	if assert.True(t, ok) {
+		assert.Equal(t, &Error{Reason: ReasonBodyTooLarge, Limit: 4}, limitErr)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, limitErr.HTTPStatus())
+	}
+
+	_, ok = As(errors.New("unexpected EOF"))
+	assert.False(t, ok)
+}
+
+func TestNewDecompressedReader(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(make([]byte, 1<<20))
+	gz.Close()
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{"Exactly at limit", 1 << 20, false},
+		{"Over limit", 1<<20 - 1, true},
+		{"Disabled", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gr, err := gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := io.ReadAll(NewDecompressedReader(gr, tt.limit))
+			if tt.wantErr {
+				limitErr, ok := As(err)
+				if assert.True(t, ok) {
+					assert.Equal(t, ReasonDecompressedTooLarge, limitErr.Reason)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, data, 1<<20)
+		})
+	}
+}
+
+func TestWriteHTTPError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteHTTPError(w, &Error{Reason: ReasonBodyTooLarge, Limit: 1024})
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"BODY_TOO_LARGE","message":"request body is larger than 1024 bytes","limit":1024}`, w.Body.String())
+}
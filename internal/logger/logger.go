@@ -1,16 +1,161 @@
 package logger
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Форматы логов.
+const (
+	// FormatJSON - JSON построчно, для сбора логов в production.
+	FormatJSON = "json"
+	// FormatConsole - человекочитаемый формат для локальной разработки.
+	FormatConsole = "console"
+)
 
 var Log *zap.Logger
 
-func Init() error {
-	var err error
-	Log, err = zap.NewDevelopment()
+// Init создает Log с уровнем level (debug, info, warn, error) и форматом format.
+//
+// Сэмплирование отключено, чтобы строки журнала доступа не терялись под нагрузкой.
+func Init(level, format string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	var cfg zap.Config
+	switch format {
+	case FormatJSON:
+		cfg = zap.NewProductionConfig()
+		cfg.EncoderConfig.TimeKey = "ts"
+		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case FormatConsole:
+		cfg = zap.NewDevelopmentConfig()
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+	cfg.Sampling = nil
 
+	Log, err = cfg.Build()
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// FromContext возвращает Log с идентификатором запроса и идентификаторами
+// трассы и span из ctx, чтобы строки лога можно было сопоставить с запросом.
+func FromContext(ctx context.Context) *zap.Logger {
+	var fields []zap.Field
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields = append(fields,
+			zap.String("trace_id", spanCtx.TraceID().String()),
+			zap.String("span_id", spanCtx.SpanID().String()),
+		)
+	}
+
+	if len(fields) == 0 {
+		return Log
+	}
+
+	return Log.With(fields...)
+}
+
+// maxRequestIDLength ограничивает длину идентификатора запроса, принятого от клиента.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDOrNew возвращает идентификатор запроса от клиента, если он допустим,
+// иначе новый UUID. Допустимы непустые строки до 128 символов из латинских
+// букв, цифр и знаков "-", "_", ".", ":".
+func RequestIDOrNew(candidate string) string {
+	if validRequestID(candidate) {
+		return candidate
+	}
+
+	return uuid.NewString()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContextWithRequestID возвращает копию ctx с идентификатором запроса.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса из ctx или пустую строку.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+type accessKey struct{}
+
+// access собирает сведения для строки журнала доступа, которые становятся
+// известны только внутренним обработчикам запроса.
+type access struct {
+	mu     sync.Mutex
+	userID string
+}
+
+// ContextWithAccess возвращает копию ctx, в которую внутренние обработчики
+// могут записать сведения для строки журнала доступа.
+func ContextWithAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, accessKey{}, &access{})
+}
+
+// SetAccessUserID запоминает пользователя запроса для журнала доступа.
+// Если ctx не создан ContextWithAccess, ничего не делает.
+func SetAccessUserID(ctx context.Context, userID string) {
+	a, ok := ctx.Value(accessKey{}).(*access)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	a.userID = userID
+	a.mu.Unlock()
+}
+
+// AccessUserID возвращает пользователя, записанный SetAccessUserID.
+func AccessUserID(ctx context.Context) string {
+	a, ok := ctx.Value(accessKey{}).(*access)
+	if !ok {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.userID
+}
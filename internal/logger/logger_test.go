@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	Log = zap.New(core)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	FromContext(ctx).Info("traced")
+	FromContext(context.Background()).Info("untraced")
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, map[string]interface{}{
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+		}, entries[0].ContextMap())
+		assert.Empty(t, entries[1].ContextMap())
+	}
+
+	FromContext(ContextWithRequestID(ctx, "req-1")).Info("with request id")
+	assert.Equal(t, "req-1", logs.All()[2].ContextMap()["request_id"])
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		wantErr bool
+	}{
+		{name: "json", level: "info", format: FormatJSON},
+		{name: "console debug", level: "debug", format: FormatConsole},
+		{name: "unknown level", level: "verbose", format: FormatJSON, wantErr: true},
+		{name: "unknown format", level: "info", format: "xml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Init(tt.level, tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.level == "debug", Log.Core().Enabled(zap.DebugLevel))
+			}
+		})
+	}
+}
+
+func TestRequestIDOrNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate string
+		keep      bool
+	}{
+		{name: "valid", candidate: "abc-123_x.y:z", keep: true},
+		{name: "empty", candidate: ""},
+		{name: "spaces", candidate: "abc 123"},
+		{name: "header injection", candidate: "abc\r\nX-Evil: 1"},
+		{name: "too long", candidate: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequestIDOrNew(tt.candidate)
+			if tt.keep {
+				assert.Equal(t, tt.candidate, got)
+				return
+			}
+
+			assert.NotEqual(t, tt.candidate, got)
+			assert.True(t, validRequestID(got))
+		})
+	}
+}
+
+func TestAccessUserID(t *testing.T) {
+	SetAccessUserID(context.Background(), "ignored")
+	assert.Empty(t, AccessUserID(context.Background()))
+
+	ctx := ContextWithAccess(context.Background())
+	SetAccessUserID(ctx, "user-1")
+	assert.Equal(t, "user-1", AccessUserID(ctx))
+}
@@ -0,0 +1,101 @@
+// Модуль metrics собирает метрики сервиса в формате Prometheus.
+//
+// Метрики регистрируются в стандартном реестре вместе с метриками
+// среды выполнения Go и процесса и отдаются через Handler.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortener"
+
+// Исходы перехода по сокращенному URL.
+const (
+	RedirectFound   = "found"
+	RedirectGone    = "gone"
+	RedirectMissing = "missing"
+)
+
+// Результаты доставки событий аудита.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+var (
+	// HTTPRequests считает HTTP-запросы по методу, шаблону маршрута chi и коду ответа.
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+
+	// HTTPDuration - время обработки HTTP-запросов по методу и шаблону маршрута.
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// GRPCRequests считает вызовы gRPC по полному имени метода и коду ответа.
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	// GRPCDuration - время обработки вызовов gRPC по методу.
+	GRPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC call latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// StorageDuration - время операций хранилища URL.
+	StorageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "URL storage operation latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	// StorageErrors считает неожиданные ошибки операций хранилища URL.
+	StorageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "errors_total",
+		Help:      "URL storage operation errors by operation.",
+	}, []string{"operation"})
+
+	// AuditDeliveries считает доставку событий аудита наблюдателям.
+	AuditDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "audit",
+		Name:      "deliveries_total",
+		Help:      "Audit event deliveries by observer and result.",
+	}, []string{"observer", "result"})
+
+	// Redirects считает переходы по сокращенным URL по исходу.
+	Redirects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Short URL redirects by outcome (found, gone, missing).",
+	}, []string{"outcome"})
+)
+
+// Handler отдает метрики стандартного реестра.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
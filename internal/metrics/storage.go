@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/storage"
+)
+
+// Storage измеряет время и ошибки операций хранилища URL.
+//
+// Отсутствующий, истекший или уже занятый URL ошибкой хранилища не считается.
+type Storage struct {
+	storage.URLStorage
+}
+
+// NewStorage оборачивает store сбором метрик.
+func NewStorage(store storage.URLStorage) *Storage {
+	return &Storage{URLStorage: store}
+}
+
+// Save сохраняет URL в хранилище.
+func (s *Storage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) (err error) {
+	defer observeStorage("save", time.Now(), &err)
+	return s.URLStorage.Save(ctx, shortURL, originalURL, userID, expiresAt)
+}
+
+// Get возвращает оригинальный URL по сокращенному.
+func (s *Storage) Get(ctx context.Context, shortURL string) (_ string, err error) {
+	defer observeStorage("get", time.Now(), &err)
+	return s.URLStorage.Get(ctx, shortURL)
+}
+
+// GetByUser возвращает пары URL пользователя.
+func (s *Storage) GetByUser(ctx context.Context, userID string) (_ []model.URLPair, err error) {
+	defer observeStorage("get_by_user", time.Now(), &err)
+	return s.URLStorage.GetByUser(ctx, userID)
+}
+
+// DeleteByUser удаляет сокращенные URL пользователя.
+func (s *Storage) DeleteByUser(ctx context.Context, userID string, shortURL []string) (err error) {
+	defer observeStorage("delete_by_user", time.Now(), &err)
+	return s.URLStorage.DeleteByUser(ctx, userID, shortURL)
+}
+
+// GetStats возвращает количество URL и пользователей.
+func (s *Storage) GetStats(ctx context.Context) (_ *model.Stats, err error) {
+	defer observeStorage("get_stats", time.Now(), &err)
+	return s.URLStorage.GetStats(ctx)
+}
+
+// DeleteExpired удаляет URL с истекшим сроком действия.
+func (s *Storage) DeleteExpired(ctx context.Context) (_ int64, err error) {
+	defer observeStorage("delete_expired", time.Now(), &err)
+	return s.URLStorage.DeleteExpired(ctx)
+}
+
+// Ping проверяет доступность хранилища.
+func (s *Storage) Ping(ctx context.Context) (err error) {
+	defer observeStorage("ping", time.Now(), &err)
+	return s.URLStorage.Ping(ctx)
+}
+
+// GetEntry возвращает запись о сокращенном URL.
+//
+// Если хранилище не реализует storage.EntryGetter, запись строится по результату Get.
+func (s *Storage) GetEntry(ctx context.Context, shortURL string) (_ *model.URLEntry, err error) {
+	defer observeStorage("get_entry", time.Now(), &err)
+
+	if getter, ok := s.URLStorage.(storage.EntryGetter); ok {
+		return getter.GetEntry(ctx, shortURL)
+	}
+
+	originalURL, err := s.URLStorage.Get(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.URLEntry{OriginalURL: originalURL, IsDeleted: originalURL == ""}, nil
+}
+
+// IterateByUser обходит пары URL пользователя. Время включает обработку в fn.
+func (s *Storage) IterateByUser(ctx context.Context, userID string, fn func(model.URLPair) error) (err error) {
+	defer observeStorage("iterate_by_user", time.Now(), &err)
+	return storage.IterateByUser(ctx, s.URLStorage, userID, fn)
+}
+
+func observeStorage(operation string, start time.Time, err *error) {
+	StorageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+	if *err != nil && !isExpectedStorageError(*err) {
+		StorageErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// isExpectedStorageError сообщает, является ли ошибка штатным результатом операции
+// или следствием отмены запроса клиентом.
+func isExpectedStorageError(err error) bool {
+	var uniqueErr *model.UniqueViolationError
+
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, model.ErrShortURLNotFound) ||
+		errors.Is(err, model.ErrURLExpired) ||
+		errors.Is(err, model.ErrShortURLExists) ||
+		errors.As(err, &uniqueErr)
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubStorage возвращает заданные URL и ошибку из всех операций.
+type stubStorage struct {
+	urls map[string]string
+	err  error
+}
+
+func (s *stubStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	return s.err
+}
+
+func (s *stubStorage) Get(ctx context.Context, shortURL string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	url, ok := s.urls[shortURL]
+	if !ok {
+		return "", model.ErrShortURLNotFound
+	}
+	return url, nil
+}
+
+func (s *stubStorage) GetByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
+	return []model.URLPair{{ShortURL: "a", OriginalURL: "https://a.com"}}, s.err
+}
+
+func (s *stubStorage) DeleteByUser(ctx context.Context, userID string, shortURL []string) error {
+	return s.err
+}
+
+func (s *stubStorage) GetStats(ctx context.Context) (*model.Stats, error) {
+	return &model.Stats{}, s.err
+}
+
+func (s *stubStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, s.err
+}
+
+func (s *stubStorage) Ping(ctx context.Context) error {
+	return s.err
+}
+
+func TestStorage_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		err       error
+		call      func(s *Storage) error
+		wantError bool
+	}{
+		{"Success", "save", nil, func(s *Storage) error {
+			return s.Save(context.Background(), "a", "https://a.com", "u", nil)
+		}, false},
+		{"Unexpected error", "save", errors.New("disk full"), func(s *Storage) error {
+			return s.Save(context.Background(), "a", "https://a.com", "u", nil)
+		}, true},
+		{"Taken short URL is not an error", "save", model.ErrShortURLExists, func(s *Storage) error {
+			return s.Save(context.Background(), "a", "https://a.com", "u", nil)
+		}, false},
+		{"Missing URL is not an error", "get", nil, func(s *Storage) error {
+			_, err := s.Get(context.Background(), "missing")
+			return err
+		}, false},
+		{"Canceled request is not an error", "get_stats", context.Canceled, func(s *Storage) error {
+			_, err := s.GetStats(context.Background())
+			return err
+		}, false},
+		{"Failed ping", "ping", errors.New("connection refused"), func(s *Storage) error {
+			return s.Ping(context.Background())
+		}, true},
+		{"Iteration error", "iterate_by_user", errors.New("connection reset"), func(s *Storage) error {
+			return s.IterateByUser(context.Background(), "u", func(model.URLPair) error { return nil })
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStorage(&stubStorage{err: tt.err})
+
+			errorsBefore := testutil.ToFloat64(StorageErrors.WithLabelValues(tt.operation))
+
+			_ = tt.call(s)
+
+			errorsDelta := testutil.ToFloat64(StorageErrors.WithLabelValues(tt.operation)) - errorsBefore
+			if tt.wantError {
+				assert.Equal(t, 1.0, errorsDelta)
+			} else {
+				assert.Equal(t, 0.0, errorsDelta)
+			}
+		})
+	}
+}
+
+func TestStorage_GetEntryFallback(t *testing.T) {
+	s := NewStorage(&stubStorage{urls: map[string]string{"a": "https://a.com", "deleted": ""}})
+
+	entry, err := s.GetEntry(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.URLEntry{OriginalURL: "https://a.com"}, entry)
+
+	entry, err = s.GetEntry(context.Background(), "deleted")
+	assert.NoError(t, err)
+	assert.True(t, entry.IsDeleted)
+
+	_, err = s.GetEntry(context.Background(), "missing")
+	assert.ErrorIs(t, err, model.ErrShortURLNotFound)
+}
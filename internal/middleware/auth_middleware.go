@@ -2,65 +2,53 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/noedaka/go-url-shortener/internal/auth"
 	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/logger"
 	"github.com/noedaka/go-url-shortener/internal/model"
 )
 
 const (
 	cookieName = "session_token"
-	secretKey  = "supersecretkey"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(cookieName)
-		if err != nil {
-			userID := setNewCookie(w)
-			ctx := context.WithValue(r.Context(), config.UserIDKey, userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
+// newUserKey помечает в контексте пользователя, созданного для текущего запроса.
+const newUserKey model.ContextKey = "new_user"
+
+// AuthMiddleware определяет пользователя по токену в cookie session_token.
+//
+// Если cookie нет или токен недействителен, создается новый пользователь
+// и выставляется cookie с новым токеном. Такой пользователь помечается в контексте
+// как новый, чтобы RateLimitMiddleware ограничивал его по IP-адресу.
+func AuthMiddleware(tokens *auth.TokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var userID string
 
-		tokenStr := cookie.Value
-		claims := &model.Claims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			cookie, err := r.Cookie(cookieName)
+			if err == nil {
+				userID, err = tokens.Parse(cookie.Value)
+			}
+			ctx := r.Context()
+			if err != nil {
+				userID = setNewCookie(w, tokens)
+				ctx = context.WithValue(ctx, newUserKey, true)
 			}
-			return []byte(secretKey), nil
-		})
 
-		if err != nil || !token.Valid {
-			userID := setNewCookie(w)
-			ctx := context.WithValue(r.Context(), config.UserIDKey, userID)
+			logger.SetAccessUserID(ctx, userID)
+			ctx = context.WithValue(ctx, config.UserIDKey, userID)
 			next.ServeHTTP(w, r.WithContext(ctx))
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), config.UserIDKey, claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+		})
+	}
 }
 
-func setNewCookie(w http.ResponseWriter) string {
+func setNewCookie(w http.ResponseWriter, tokens *auth.TokenService) string {
 	userID := uuid.New().String()
-	expiresAt := time.Now().Add(24 * time.Hour)
-
-	claims := &model.Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-		},
-	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secretKey))
+	tokenString, expiresAt, err := tokens.Issue(userID)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return userID
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/noedaka/go-url-shortener/internal/limits"
+)
+
+// BodyLimitMiddleware ограничивает размер тела запроса maxSize байтами.
+//
+// Запрос с заведомо большим Content-Length отклоняется с кодом 413 сразу,
+// в остальных случаях ошибку возвращает чтение тела, и ее обрабатывает хэндлер.
+// Должен стоять перед GzipMiddleware, чтобы ограничивался размер сжатого тела.
+// Конфигурация всегда задает положительный maxSize; при maxSize <= 0, что возможно
+// только при создании middleware вне конфигурации, размер не ограничивается.
+func BodyLimitMiddleware(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxSize <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				limits.WriteHTTPError(w, &limits.Error{Reason: limits.ReasonBodyTooLarge, Limit: maxSize})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/noedaka/go-url-shortener/internal/tlsutil"
+)
+
+// ClientCertMiddleware пропускает только запросы, клиент которых предъявил
+// сертификат, подписанный доверенным CA. Остальным отвечает 403.
+func ClientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tlsutil.HasVerifiedClientCert(r.TLS) {
+			http.Error(w, "Client certificate required", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
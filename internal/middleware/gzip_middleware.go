@@ -2,10 +2,12 @@ package middleware
 
 import (
 	"compress/gzip"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/noedaka/go-url-shortener/internal/limits"
 )
 
 var compressibleContentTypes = map[string]bool{
@@ -26,37 +28,61 @@ func (w *gzipResponseWriter) Close() error {
 	return w.gzipWriter.Close()
 }
 
-func GzipMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
-			gz, err := gzip.NewReader(r.Body)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
-			}
-			defer gz.Close()
-			r.Body = gz
-		}
-
-		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-
-		acceptsGzip := strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
-		if acceptsGzip {
-			contentType := ww.Header().Get("Content-Type")
-			if compressibleContentTypes[contentType] {
-				ww.Header().Set("Content-Encoding", "gzip")
-				gz := gzip.NewWriter(ww)
+// gzipRequestBody читает распакованное тело запроса с ограничением размера.
+type gzipRequestBody struct {
+	io.Reader
+	gz *gzip.Reader
+}
+
+func (b *gzipRequestBody) Close() error {
+	return b.gz.Close()
+}
+
+// GzipMiddleware распаковывает тела запросов в gzip и сжимает ответы клиентам,
+// принимающим gzip.
+//
+// Распакованное тело ограничено maxDecompressedSize байтами: при превышении
+// чтение тела возвращает ошибку limits.ReasonDecompressedTooLarge.
+func GzipMiddleware(maxDecompressedSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
+				gz, err := gzip.NewReader(r.Body)
+				if err != nil {
+					if limitErr, ok := limits.As(err); ok {
+						limits.WriteHTTPError(w, limitErr)
+						return
+					}
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
 				defer gz.Close()
+				r.Body = &gzipRequestBody{
+					Reader: limits.NewDecompressedReader(gz, maxDecompressedSize),
+					gz:     gz,
+				}
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			acceptsGzip := strings.Contains(r.Header.Get("Accept-Encoding"), "gzip")
+			if acceptsGzip {
+				contentType := ww.Header().Get("Content-Type")
+				if compressibleContentTypes[contentType] {
+					ww.Header().Set("Content-Encoding", "gzip")
+					gz := gzip.NewWriter(ww)
+					defer gz.Close()
 
-				gzipW := &gzipResponseWriter{
-					WrapResponseWriter: ww,
-					gzipWriter:         gz,
+					gzipW := &gzipResponseWriter{
+						WrapResponseWriter: ww,
+						gzipWriter:         gz,
+					}
+					next.ServeHTTP(gzipW, r)
+					return
 				}
-				next.ServeHTTP(gzipW, r)
-				return
 			}
-		}
 
-		next.ServeHTTP(ww, r)
-	})
+			next.ServeHTTP(ww, r)
+		})
+	}
 }
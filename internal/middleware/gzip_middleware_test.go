@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimitAndGzipMiddleware(t *testing.T) {
+	// readHandler читает тело так же, как хэндлеры сервиса.
+	readHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if limitErr, ok := limits.As(err); ok {
+				limits.WriteHTTPError(w, limitErr)
+				return
+			}
+			http.Error(w, "cannot read body", http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	})
+
+	compress := func(data []byte) []byte {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		gz.Close()
+		return buf.Bytes()
+	}
+
+	// Мегабайт нулей сжимается примерно в килобайт.
+	bomb := compress(make([]byte, 1<<20))
+
+	// Случайные данные не сжимаются и превышают ограничение уже в сжатом виде.
+	random := make([]byte, 8<<10)
+	rand.Read(random)
+
+	tests := []struct {
+		name          string
+		body          []byte
+		gzip          bool
+		chunked       bool
+		wantStatus    int
+		wantReason    string
+		wantBodyBytes int
+	}{
+		{"Plain body within limit", []byte("https://example.com"), false, false, http.StatusOK, "", 19},
+		{"Plain body over limit", make([]byte, 8<<10), false, false, http.StatusRequestEntityTooLarge, limits.ReasonBodyTooLarge, 0},
+		{"Chunked body over limit", make([]byte, 8<<10), false, true, http.StatusRequestEntityTooLarge, limits.ReasonBodyTooLarge, 0},
+		{"Gzip body within limits", compress([]byte("https://example.com")), true, false, http.StatusOK, "", 19},
+		{"Gzip bomb", bomb, true, false, http.StatusRequestEntityTooLarge, limits.ReasonDecompressedTooLarge, 0},
+		{"Compressed body over limit", compress(random), true, true, http.StatusRequestEntityTooLarge, limits.ReasonBodyTooLarge, 0},
+	}
+
+	handler := BodyLimitMiddleware(4 << 10)(GzipMiddleware(64 << 10)(readHandler))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.gzip {
+				r.Header.Set("Content-Encoding", "gzip")
+			}
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			if tt.wantReason != "" {
+				var resp limits.ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.wantReason, resp.Error)
+				return
+			}
+
+			assert.Len(t, w.Body.Bytes(), tt.wantBodyBytes)
+		})
+	}
+}
@@ -4,28 +4,45 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/noedaka/go-url-shortener/internal/logger"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// LoggingMiddleware пишет одну строку журнала доступа после обработки запроса.
+//
+// Строка содержит метод, шаблон маршрута, путь, код ответа, размер ответа,
+// время обработки и пользователя, а также идентификаторы запроса и трассы из контекста.
+// Ответы 5xx пишутся с уровнем error.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		ctx := logger.ContextWithAccess(r.Context())
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-		logger.Log.Info("request",
-			zap.String("path", r.RequestURI),
-			zap.String("method", r.Method),
-			zap.Duration("duration", time.Since(start)),
-		)
+		next.ServeHTTP(ww, r.WithContext(ctx))
 
-		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		route := ""
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			route = rctx.RoutePattern()
+		}
 
-		next.ServeHTTP(ww, r)
+		level := zapcore.InfoLevel
+		if ww.Status() >= http.StatusInternalServerError {
+			level = zapcore.ErrorLevel
+		}
 
-		logger.Log.Info("answer",
-			zap.Int("status code", ww.Status()),
+		logger.FromContext(ctx).Log(level, "http request",
+			zap.String("method", r.Method),
+			zap.String("route", route),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", ww.Status()),
 			zap.Int("bytes", ww.BytesWritten()),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("user_id", logger.AccessUserID(ctx)),
+			zap.String("remote_addr", r.RemoteAddr),
 		)
 	})
 }
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger.Log = zap.New(core)
+	t.Cleanup(func() { logger.Log = zap.NewNop() })
+
+	r := chi.NewRouter()
+	r.Use(RequestIDMiddleware)
+	r.Use(LoggingMiddleware)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.SetAccessUserID(r.Context(), "user-1")
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		w.Write([]byte("moved"))
+	})
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		requestID string
+		wantRoute string
+		wantCode  int
+		wantLevel zapcore.Level
+		wantUser  string
+		wantBytes int64
+	}{
+		{
+			name:      "client request id is kept",
+			method:    http.MethodGet,
+			target:    "/abc",
+			requestID: "req-42",
+			wantRoute: "/{id}",
+			wantCode:  http.StatusTemporaryRedirect,
+			wantLevel: zapcore.InfoLevel,
+			wantUser:  "user-1",
+			wantBytes: int64(len("moved")),
+		},
+		{
+			name:      "invalid request id is replaced",
+			method:    http.MethodPost,
+			target:    "/",
+			requestID: "bad id\n" + strings.Repeat("x", 200),
+			wantRoute: "/",
+			wantCode:  http.StatusInternalServerError,
+			wantLevel: zapcore.ErrorLevel,
+			wantBytes: int64(len("boom\n")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set(RequestIDHeader, tt.requestID)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, requestID)
+			if tt.wantLevel == zapcore.InfoLevel {
+				assert.Equal(t, tt.requestID, requestID)
+			} else {
+				assert.NotEqual(t, tt.requestID, requestID)
+			}
+
+			entries := logs.FilterMessage("http request").AllUntimed()
+			if !assert.Len(t, entries, 1, "one access line per request") {
+				return
+			}
+
+			entry := entries[0]
+			fields := entry.ContextMap()
+			assert.Equal(t, tt.wantLevel, entry.Level)
+			assert.Equal(t, tt.method, fields["method"])
+			assert.Equal(t, tt.wantRoute, fields["route"])
+			assert.Equal(t, tt.target, fields["path"])
+			assert.Equal(t, int64(tt.wantCode), fields["status"])
+			assert.Equal(t, tt.wantBytes, fields["bytes"])
+			assert.Equal(t, tt.wantUser, fields["user_id"])
+			assert.Equal(t, requestID, fields["request_id"])
+			assert.Contains(t, fields, "duration")
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/noedaka/go-url-shortener/internal/metrics"
+)
+
+// MetricsMiddleware считает запросы и время их обработки по шаблону маршрута chi.
+//
+// Шаблон вместо пути запроса не дает сокращенным ID раздувать число меток.
+// Запросы, не попавшие ни в один маршрут, учитываются с маршрутом "unmatched",
+// а запросы с нестандартным методом - с методом "OTHER".
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		method := metricMethod(r.Method)
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(ww.Status())).Inc()
+		metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// standardMethods - методы HTTP, которые учитываются в метриках под своим именем.
+var standardMethods = map[string]struct{}{
+	http.MethodGet:     {},
+	http.MethodHead:    {},
+	http.MethodPost:    {},
+	http.MethodPut:     {},
+	http.MethodPatch:   {},
+	http.MethodDelete:  {},
+	http.MethodConnect: {},
+	http.MethodOptions: {},
+	http.MethodTrace:   {},
+}
+
+// metricMethod возвращает метку метода запроса. Клиент может прислать любой
+// токен в качестве метода, поэтому нестандартные методы объединяются в "OTHER".
+func metricMethod(method string) string {
+	if _, ok := standardMethods[method]; ok {
+		return method
+	}
+
+	return "OTHER"
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/noedaka/go-url-shortener/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(MetricsMiddleware)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	byID := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/{id}", "307")
+	unmatched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")
+	byIDBefore, unmatchedBefore := testutil.ToFloat64(byID), testutil.ToFloat64(unmatched)
+
+	for _, path := range []string{"/abc", "/xyz", "/a/b"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(byID)-byIDBefore, "requests must be grouped by route pattern")
+	assert.Equal(t, 1.0, testutil.ToFloat64(unmatched)-unmatchedBefore)
+}
+
+func TestMetricsMiddleware_NonStandardMethod(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(MetricsMiddleware)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	other := metrics.HTTPRequests.WithLabelValues("OTHER", "unmatched", "405")
+	before := testutil.ToFloat64(other)
+
+	for _, method := range []string{"FOO", "BAR", "get"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(other)-before, "non-standard methods must share one label")
+}
+
+func TestTrustedSubnetMiddleware(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		subnet string
+		realIP string
+		want   int
+	}{
+		{"Subnet not configured", "", "10.0.0.1", http.StatusServiceUnavailable},
+		{"Address in subnet", "10.0.0.0/24", "10.0.0.1", http.StatusOK},
+		{"Address outside subnet", "10.0.0.0/24", "10.0.1.1", http.StatusForbidden},
+		{"No X-Real-IP", "10.0.0.0/24", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			w := httptest.NewRecorder()
+			TrustedSubnetMiddleware(tt.subnet)(okHandler).ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
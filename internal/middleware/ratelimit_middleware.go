@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// RateLimitMiddleware ограничивает частоту запросов клиента лимитом limiter.
+//
+// Клиент определяется по keyBy: по пользователю из контекста (тогда middleware
+// должен стоять после AuthMiddleware) или по IP-адресу соединения. Пользователь,
+// созданный AuthMiddleware для этого же запроса, ограничивается по IP-адресу:
+// иначе клиент без cookie получал бы новый лимит на каждый запрос.
+// При превышении лимита отвечает 429 с заголовком Retry-After.
+// Если хранилище лимитов недоступно, запрос пропускается.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, keyBy string) func(http.Handler) http.Handler {
+	burst := strconv.Itoa(limiter.Limit().Burst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(config.UserIDKey).(string)
+			if isNew, _ := r.Context().Value(newUserKey).(bool); isNew {
+				userID = ""
+			}
+			key := ratelimit.ClientKey(keyBy, userID, remoteIP(r))
+
+			res, err := limiter.Allow(r.Context(), key)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("rate limit check failed, allowing request", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", burst)
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(res.RetryAfter)))
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/auth"
+	"github.com/noedaka/go-url-shortener/internal/config"
+	"github.com/noedaka/go-url-shortener/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	newRequest := func(userID, remoteAddr string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		r.RemoteAddr = remoteAddr
+		if userID != "" {
+			r = r.WithContext(context.WithValue(r.Context(), config.UserIDKey, userID))
+		}
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		keyBy  string
+		second *http.Request
+		want   int
+	}{
+		{"Same user from another IP is limited", ratelimit.KeyUser, newRequest("user1", "10.0.0.2:1234"), http.StatusTooManyRequests},
+		{"Another user from the same IP passes", ratelimit.KeyUser, newRequest("user2", "10.0.0.1:1234"), http.StatusOK},
+		{"Same IP is limited by IP key", ratelimit.KeyIP, newRequest("user2", "10.0.0.1:4321"), http.StatusTooManyRequests},
+		{"Another IP passes by IP key", ratelimit.KeyIP, newRequest("user1", "10.0.0.2:1234"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := ratelimit.NewLimiter(ratelimit.NewMemory(), "test", ratelimit.Limit{Rate: 0.5, Burst: 1})
+			handler := RateLimitMiddleware(limiter, tt.keyBy)(okHandler)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newRequest("user1", "10.0.0.1:1234"))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+			assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.second)
+			assert.Equal(t, tt.want, w.Code)
+
+			if tt.want == http.StatusTooManyRequests {
+				assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_CookielessRequests(t *testing.T) {
+	tokens, err := auth.NewTokenService(auth.KeySet{
+		Keys: []auth.Key{{ID: "test", Secret: strings.Repeat("s", auth.MinSecretLength)}},
+	}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemory(), "test", ratelimit.Limit{Rate: 0.5, Burst: 2})
+	handler := AuthMiddleware(tokens)(RateLimitMiddleware(limiter, ratelimit.KeyUser)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	send := func(remoteAddr string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		r.RemoteAddr = remoteAddr
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := send("10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, send("10.0.0.1:1235").Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.1:1236").Code,
+		"cookieless requests from one IP must share a limit")
+
+	assert.Equal(t, http.StatusOK, send("10.0.0.2:1234").Code, "another IP has its own limit")
+
+	cookies := first.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, http.StatusOK, send("10.0.0.1:1237", cookies[0]).Code,
+			"a returning user is limited by user, not by the exhausted IP")
+	}
+}
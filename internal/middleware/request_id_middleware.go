@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/noedaka/go-url-shortener/internal/logger"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware принимает идентификатор запроса из заголовка X-Request-ID
+// или создает новый, кладет его в контекст и возвращает клиенту в том же заголовке.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := logger.RequestIDOrNew(r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := logger.ContextWithRequestID(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	var handlerSpan trace.SpanContext
+	r := chi.NewRouter()
+	r.Use(TracingMiddleware)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+
+	redirect := spans[0]
+	assert.Equal(t, "GET /{id}", redirect.Name())
+	assert.Equal(t, trace.SpanKindServer, redirect.SpanKind())
+	assert.Equal(t, traceID, redirect.SpanContext().TraceID().String(), "trace must continue the caller's trace")
+	assert.Equal(t, "00f067aa0ba902b7", redirect.Parent().SpanID().String())
+	assert.Equal(t, redirect.SpanContext().SpanID(), handlerSpan.SpanID(), "handler must see the server span")
+
+	failed := spans[1]
+	assert.Equal(t, "POST /", failed.Name())
+	assert.NotEqual(t, traceID, failed.SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+}
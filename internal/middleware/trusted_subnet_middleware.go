@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/noedaka/go-url-shortener/internal/netutil"
+)
+
+// TrustedSubnetMiddleware пропускает только клиентов из доверенной подсети.
+//
+// Адрес клиента берется из заголовка X-Real-IP. Если подсеть не задана,
+// отвечает 503, если адрес не входит в подсеть - 403.
+func TrustedSubnetMiddleware(trustedSubnet string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if trustedSubnet == "" {
+				http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+
+			ip := net.ParseIP(r.Header.Get("X-Real-IP"))
+			if ip == nil || !netutil.InSubnet(ip, trustedSubnet) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package migrator
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/noedaka/go-url-shortener/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "Sorted by version",
+			fsys: fstest.MapFS{
+				"000002_second.up.sql":   {Data: []byte("CREATE INDEX i ON t (c);")},
+				"000002_second.down.sql": {Data: []byte("DROP INDEX i;")},
+				"000001_first.up.sql":    {Data: []byte("CREATE TABLE t (c TEXT);")},
+				"000001_first.down.sql":  {Data: []byte("DROP TABLE t;")},
+				"README.md":              {Data: []byte("# migrations")},
+			},
+			versions: []int64{1, 2},
+		},
+		{
+			name: "Missing down file",
+			fsys: fstest.MapFS{
+				"000001_first.up.sql": {Data: []byte("CREATE TABLE t (c TEXT);")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Conflicting names",
+			fsys: fstest.MapFS{
+				"000001_first.up.sql":   {Data: []byte("CREATE TABLE t (c TEXT);")},
+				"000001_other.down.sql": {Data: []byte("DROP TABLE t;")},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Empty directory",
+			fsys:     fstest.MapFS{},
+			versions: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			versions := make([]int64, 0, len(got))
+			for _, m := range got {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tt.versions, versions)
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, got)
+
+	for i, m := range got {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions must have no gaps")
+		assert.NotContains(t, m.Down, " ON urls", "DROP INDEX does not take a table in PostgreSQL")
+	}
+}
@@ -1,11 +1,28 @@
 package model
 
-import "github.com/golang-jwt/jwt/v4"
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrShortURLExists возвращается хранилищем, если сокращенный URL уже занят.
+	ErrShortURLExists = errors.New("short url already exists")
+	// ErrURLExpired возвращается хранилищем, если срок действия сокращенного URL истек.
+	ErrURLExpired = errors.New("url has expired")
+	// ErrShortURLNotFound возвращается хранилищем, если сокращенный URL не существует.
+	ErrShortURLNotFound = errors.New("short url not found")
+)
 
 type ContextKey string
 
 type Request struct {
-	URL string `json:"url"`
+	URL        string     `json:"url"`
+	Alias      string     `json:"alias,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty"`
 }
 
 type Response struct {
@@ -13,8 +30,11 @@ type Response struct {
 }
 
 type BatchRequest struct {
-	CorrelationID string `json:"correlation_id"`
-	URL           string `json:"original_url"`
+	CorrelationID string     `json:"correlation_id"`
+	URL           string     `json:"original_url"`
+	Alias         string     `json:"alias,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds    int64      `json:"ttl_seconds,omitempty"`
 }
 
 type BatchResponse struct {
@@ -22,11 +42,44 @@ type BatchResponse struct {
 	ShortURL      string `json:"short_url"`
 }
 
+// ShortenOptions содержит необязательные параметры сокращения URL.
+type ShortenOptions struct {
+	// Alias задает желаемый сокращенный URL вместо сгенерированного.
+	Alias string
+	// ExpiresAt задает момент, после которого сокращенный URL перестает работать.
+	ExpiresAt *time.Time
+	// TTLSeconds задает время жизни сокращенного URL в секундах.
+	// Не может использоваться одновременно с ExpiresAt.
+	TTLSeconds int64
+}
+
 type URLPair struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
 }
 
+// URLEntry содержит сохраненный сокращенный URL вместе с его состоянием.
+type URLEntry struct {
+	OriginalURL string     `json:"original_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	IsDeleted   bool       `json:"is_deleted,omitempty"`
+}
+
+// Resolve возвращает оригинальный URL с учетом состояния записи на момент now.
+//
+// Для удаленного URL возвращается пустая строка, для истекшего - ErrURLExpired.
+func (e *URLEntry) Resolve(now time.Time) (string, error) {
+	if e.IsDeleted {
+		return "", nil
+	}
+
+	if e.ExpiresAt != nil && !e.ExpiresAt.After(now) {
+		return "", ErrURLExpired
+	}
+
+	return e.OriginalURL, nil
+}
+
 type UniqueViolationError struct {
 	ShortID string
 	Err     error
@@ -52,6 +105,40 @@ type AuditEvent struct {
 type Stats struct {
 	URLs  int `json:"urls"`
 	Users int `json:"users"`
+	// Cache содержит счетчики кэша сокращенных URL, если кэш включен.
+	Cache *CacheStats `json:"cache,omitempty"`
+}
+
+// CacheStats содержит количество попаданий и промахов кэша.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// Click описывает один переход по сокращенному URL.
+type Click struct {
+	ShortURL  string    `json:"short_url"`
+	Timestamp time.Time `json:"ts"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	// IP содержит адрес клиента с обнуленной младшей частью.
+	IP string `json:"ip,omitempty"`
+	// VisitorID идентифицирует посетителя для подсчета уникальных переходов.
+	VisitorID string `json:"visitor_id"`
+}
+
+// ClickBucket содержит количество переходов за интервал, начинающийся в Start.
+type ClickBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+// ClickStats содержит статистику переходов по сокращенному URL.
+type ClickStats struct {
+	TotalClicks    int           `json:"total_clicks"`
+	UniqueVisitors int           `json:"unique_visitors"`
+	Hourly         []ClickBucket `json:"hourly"`
+	Daily          []ClickBucket `json:"daily"`
 }
 
 func (e *UniqueViolationError) Error() string {
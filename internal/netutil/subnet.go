@@ -0,0 +1,19 @@
+// Модуль netutil содержит вспомогательные функции для работы с сетевыми адресами.
+package netutil
+
+import "net"
+
+// InSubnet проверяет, входит ли ip в подсеть, заданную в формате CIDR.
+// Если subnet - одиночный адрес, проверяется точное совпадение.
+func InSubnet(ip net.IP, subnet string) bool {
+	if checkIP := net.ParseIP(subnet); checkIP != nil {
+		return ip.Equal(checkIP)
+	}
+
+	_, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return false
+	}
+
+	return network.Contains(ip)
+}
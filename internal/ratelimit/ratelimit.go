@@ -0,0 +1,131 @@
+// Модуль ratelimit ограничивает частоту запросов по алгоритму token bucket.
+//
+// Состояние корзин хранится в Store: в памяти процесса для одного экземпляра
+// сервиса или в Redis, чтобы лимит был общим для нескольких экземпляров.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Способы определения клиента, к которому применяется лимит.
+const (
+	// KeyUser - по идентификатору пользователя, а при его отсутствии - по IP.
+	KeyUser = "user"
+	// KeyIP - по IP-адресу клиента.
+	KeyIP = "ip"
+)
+
+// Limit - параметры корзины: скорость пополнения и емкость.
+type Limit struct {
+	// Rate - количество запросов в секунду, на которое пополняется корзина.
+	Rate float64
+	// Burst - емкость корзины, то есть сколько запросов можно выполнить подряд.
+	Burst int
+}
+
+// ParseLimit разбирает лимит вида "10/s", "100/m:20" или "1000/h:50".
+//
+// Число после двоеточия задает емкость корзины; по умолчанию она равна
+// количеству запросов за период. Пустая строка и "0" отключают лимит.
+func ParseLimit(s string) (Limit, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, false, nil
+	}
+
+	spec, burstStr, hasBurst := strings.Cut(s, ":")
+	countStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, false, fmt.Errorf("invalid rate limit %q: expected count/period", s)
+	}
+
+	count, err := strconv.ParseFloat(countStr, 64)
+	if err != nil || count <= 0 {
+		return Limit{}, false, fmt.Errorf("invalid rate limit %q: bad count", s)
+	}
+
+	var period time.Duration
+	switch periodStr {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, false, fmt.Errorf("invalid rate limit %q: period must be s, m or h", s)
+	}
+
+	burst := int(math.Ceil(count))
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return Limit{}, false, fmt.Errorf("invalid rate limit %q: bad burst", s)
+		}
+	}
+
+	return Limit{Rate: count / period.Seconds(), Burst: burst}, true, nil
+}
+
+// Result - результат проверки лимита.
+type Result struct {
+	Allowed bool
+	// Remaining - количество запросов, которые можно выполнить сразу.
+	Remaining int
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонен.
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние корзин.
+type Store interface {
+	// Allow забирает токен из корзины key, если он есть.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter применяет лимит группы маршрутов к клиентам.
+type Limiter struct {
+	store Store
+	name  string
+	limit Limit
+}
+
+// NewLimiter создает Limiter для группы name. Корзины разных групп не пересекаются.
+func NewLimiter(store Store, name string, limit Limit) *Limiter {
+	return &Limiter{store: store, name: name, limit: limit}
+}
+
+// Allow проверяет лимит для клиента key.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.store.Allow(ctx, l.name+":"+key, l.limit)
+}
+
+// Limit возвращает параметры лимита.
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// ClientKey возвращает ключ клиента: пользователя, если keyBy равен KeyUser
+// и пользователь известен, иначе IP-адрес.
+func ClientKey(keyBy, userID, ip string) string {
+	if keyBy == KeyUser && userID != "" {
+		return "user:" + userID
+	}
+
+	return "ip:" + ip
+}
+
+// RetryAfterSeconds округляет время ожидания вверх до целых секунд для заголовка Retry-After.
+func RetryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        Limit
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{"Per second", "10/s", Limit{Rate: 10, Burst: 10}, true, false},
+		{"Per minute with burst", "120/m:5", Limit{Rate: 2, Burst: 5}, true, false},
+		{"Per hour", "3600/h", Limit{Rate: 1, Burst: 3600}, true, false},
+		{"Disabled", "0", Limit{}, false, false},
+		{"Empty", "", Limit{}, false, false},
+		{"No period", "10", Limit{}, false, true},
+		{"Bad period", "10/d", Limit{}, false, true},
+		{"Negative count", "-1/s", Limit{}, false, true},
+		{"Bad burst", "10/s:x", Limit{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, enabled, err := ParseLimit(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantEnabled, enabled)
+			assert.Equal(t, tt.want, limit)
+		})
+	}
+}
+
+// clock позволяет двигать время одинаково для обоих хранилищ.
+type clock struct {
+	now time.Time
+	mr  *miniredis.Miniredis
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	if c.mr != nil {
+		c.mr.SetTime(c.now)
+	}
+}
+
+func newStores(t *testing.T, c *clock) map[string]Store {
+	memory := NewMemory()
+	memory.now = func() time.Time { return c.now }
+
+	c.mr = miniredis.RunT(t)
+	c.mr.SetTime(c.now)
+	client := redis.NewClient(&redis.Options{Addr: c.mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return map[string]Store{
+		"memory": memory,
+		"redis":  NewRedis(client, "test:"),
+	}
+}
+
+func TestStore_TokenBucket(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+	for name, store := range newStores(t, c) {
+		t.Run(name, func(t *testing.T) {
+			limiter := NewLimiter(store, name, limit)
+
+			res, err := limiter.Allow(ctx, "client")
+			assert.NoError(t, err)
+			assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+
+			res, err = limiter.Allow(ctx, "client")
+			assert.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 0, res.Remaining)
+
+			res, err = limiter.Allow(ctx, "client")
+			assert.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.InDelta(t, time.Second, res.RetryAfter, float64(10*time.Millisecond))
+
+			// Другой клиент получает собственную корзину.
+			res, err = limiter.Allow(ctx, "other")
+			assert.NoError(t, err)
+			assert.True(t, res.Allowed)
+
+			c.advance(1500 * time.Millisecond)
+
+			res, err = limiter.Allow(ctx, "client")
+			assert.NoError(t, err)
+			assert.True(t, res.Allowed, "bucket must refill over time")
+
+			res, err = limiter.Allow(ctx, "client")
+			assert.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.InDelta(t, 500*time.Millisecond, res.RetryAfter, float64(10*time.Millisecond))
+		})
+	}
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	_, err := store.Allow(ctx, "a", Limit{Rate: 1, Burst: 1})
+	assert.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+
+	now = now.Add(2 * sweepInterval)
+	_, err = store.Allow(ctx, "b", Limit{Rate: 1, Burst: 1})
+	assert.NoError(t, err)
+
+	assert.Len(t, store.buckets, 1, "refilled bucket must be removed")
+	assert.Contains(t, store.buckets, "b")
+}
+
+func TestClientKey(t *testing.T) {
+	assert.Equal(t, "user:u1", ClientKey(KeyUser, "u1", "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", ClientKey(KeyUser, "", "10.0.0.1"))
+	assert.Equal(t, "ip:10.0.0.1", ClientKey(KeyIP, "u1", "10.0.0.1"))
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, RetryAfterSeconds(0))
+	assert.Equal(t, 1, RetryAfterSeconds(200*time.Millisecond))
+	assert.Equal(t, 2, RetryAfterSeconds(1100*time.Millisecond))
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Стратегии генерации сокращенных ID.
+const (
+	// StrategyRandom выбирает случайные символы алфавита.
+	StrategyRandom = "random"
+	// StrategyCounter кодирует монотонный счетчик в алфавите.
+	StrategyCounter = "counter"
+	// StrategyHash кодирует хеш оригинального URL в алфавите.
+	StrategyHash = "hash"
+)
+
+const (
+	// DefaultAlphabet - алфавит base62, используемый по умолчанию.
+	DefaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// DefaultIDLength - длина сокращенного ID по умолчанию.
+	DefaultIDLength = 6
+)
+
+// ErrUnknownStrategy возвращается при попытке создать генератор неизвестной стратегии.
+var ErrUnknownStrategy = errors.New("unknown id generation strategy")
+
+// IDGenerator генерирует сокращенные ID.
+type IDGenerator interface {
+	// Generate возвращает сокращенный ID для оригинального URL.
+	//
+	// attempt увеличивается при каждой повторной попытке после коллизии,
+	// чтобы детерминированные стратегии могли получить другое значение.
+	Generate(originalURL string, attempt int) string
+}
+
+// NewIDGenerator создает генератор указанной стратегии.
+//
+// counterStart используется только стратегией StrategyCounter: первым будет выдан
+// ID со значением счетчика counterStart+1.
+func NewIDGenerator(strategy, alphabet string, length int, counterStart uint64) (IDGenerator, error) {
+	if err := ValidateAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	if length <= 0 {
+		return nil, fmt.Errorf("id length must be positive, got %d", length)
+	}
+
+	switch strategy {
+	case StrategyRandom:
+		return &RandomGenerator{alphabet: alphabet, length: length}, nil
+	case StrategyCounter:
+		g := &CounterGenerator{alphabet: alphabet, length: length}
+		g.counter.Store(counterStart)
+		return g, nil
+	case StrategyHash:
+		return &HashGenerator{alphabet: alphabet, length: length}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, strategy)
+}
+
+// ValidateAlphabet проверяет, что алфавит содержит не менее двух различных символов
+// и все они - незарезервированные символы URL по RFC 3986 (A-Z, a-z, 0-9, "-", ".", "_", "~").
+// Иначе ID пришлось бы экранировать в пути, а повторяющиеся символы давали бы коллизии счетчика.
+func ValidateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return fmt.Errorf("alphabet must contain at least 2 characters, got %d", len(alphabet))
+	}
+
+	var seen [256]bool
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if !isUnreserved(c) {
+			return fmt.Errorf("alphabet must contain only unreserved URL characters, got %q", alphabet[i:i+1])
+		}
+		if seen[c] {
+			return fmt.Errorf("alphabet contains duplicate character %q", alphabet[i:i+1])
+		}
+		seen[c] = true
+	}
+
+	return nil
+}
+
+// RandomGenerator генерирует ID из случайных символов алфавита.
+type RandomGenerator struct {
+	alphabet string
+	length   int
+}
+
+// Generate возвращает случайный ID.
+func (g *RandomGenerator) Generate(originalURL string, attempt int) string {
+	b := make([]byte, g.length)
+	for i := range b {
+		b[i] = g.alphabet[rand.IntN(len(g.alphabet))]
+	}
+	return string(b)
+}
+
+// CounterGenerator генерирует ID, кодируя монотонно возрастающий счетчик.
+//
+// Если значение счетчика не помещается в заданную длину, ID получается длиннее.
+type CounterGenerator struct {
+	alphabet string
+	length   int
+	counter  atomic.Uint64
+}
+
+// Generate возвращает следующее значение счетчика в алфавите генератора.
+func (g *CounterGenerator) Generate(originalURL string, attempt int) string {
+	return encode(g.counter.Add(1), g.alphabet, g.length)
+}
+
+// DecodeCounter возвращает значение счетчика, которое CounterGenerator закодировал
+// бы в shortID с алфавитом alphabet. Если shortID содержит символы вне алфавита
+// или не помещается в uint64, возвращается false.
+//
+// Используется, чтобы после перезапуска продолжить счетчик с наибольшего выданного ID.
+func DecodeCounter(shortID, alphabet string) (uint64, bool) {
+	if shortID == "" {
+		return 0, false
+	}
+
+	base := uint64(len(alphabet))
+	var n uint64
+	for i := 0; i < len(shortID); i++ {
+		digit := strings.IndexByte(alphabet, shortID[i])
+		if digit < 0 {
+			return 0, false
+		}
+
+		hi, lo := bits.Mul64(n, base)
+		sum, carry := bits.Add64(lo, uint64(digit), 0)
+		if hi != 0 || carry != 0 {
+			return 0, false
+		}
+		n = sum
+	}
+
+	return n, true
+}
+
+// HashGenerator генерирует ID из хеша оригинального URL.
+//
+// Один и тот же URL дает один и тот же ID, при коллизиях к URL добавляется номер попытки.
+type HashGenerator struct {
+	alphabet string
+	length   int
+}
+
+// Generate возвращает ID, полученный из SHA-256 оригинального URL.
+func (g *HashGenerator) Generate(originalURL string, attempt int) string {
+	input := originalURL
+	if attempt > 0 {
+		input += "#" + strconv.Itoa(attempt)
+	}
+
+	sum := sha256.Sum256([]byte(input))
+	b := make([]byte, g.length)
+	for i := range b {
+		// Берем по два байта хеша на символ, чтобы уменьшить смещение распределения.
+		n := binary.BigEndian.Uint16(sum[(2*i)%len(sum):])
+		b[i] = g.alphabet[int(n)%len(g.alphabet)]
+	}
+	return string(b)
+}
+
+// encode кодирует число в алфавите, дополняя результат первым символом алфавита до минимальной длины.
+func encode(n uint64, alphabet string, minLength int) string {
+	base := uint64(len(alphabet))
+
+	var b []byte
+	for n > 0 {
+		b = append(b, alphabet[n%base])
+		n /= base
+	}
+
+	for len(b) < minLength {
+		b = append(b, alphabet[0])
+	}
+
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+
+	return string(b)
+}
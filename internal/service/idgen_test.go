@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+)
+
+func TestNewIDGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		alphabet string
+		length   int
+		wantErr  bool
+	}{
+		{"Random", StrategyRandom, DefaultAlphabet, 6, false},
+		{"Counter", StrategyCounter, DefaultAlphabet, 6, false},
+		{"Hash", StrategyHash, DefaultAlphabet, 8, false},
+		{"Unknown strategy", "uuid", DefaultAlphabet, 6, true},
+		{"Short alphabet", StrategyRandom, "a", 6, true},
+		{"Duplicate characters", StrategyCounter, "abca", 6, true},
+		{"Non-ASCII characters", StrategyRandom, "abcé", 6, true},
+		{"Reserved characters", StrategyRandom, "ab/?#%", 6, true},
+		{"Unreserved punctuation", StrategyRandom, "ab-._~", 6, false},
+		{"Zero length", StrategyRandom, DefaultAlphabet, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewIDGenerator(tt.strategy, tt.alphabet, tt.length, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewIDGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if id := g.Generate("https://example.com", 0); len(id) != tt.length {
+				t.Errorf("Generate() got len = %d, want %d", len(id), tt.length)
+			}
+		})
+	}
+}
+
+func TestCounterGenerator(t *testing.T) {
+	g, err := NewIDGenerator(StrategyCounter, "01", 4, 0)
+	if err != nil {
+		t.Fatalf("NewIDGenerator() error = %v", err)
+	}
+
+	for _, want := range []string{"0001", "0010", "0011"} {
+		if got := g.Generate("", 0); got != want {
+			t.Errorf("Generate() got = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDecodeCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		shortID string
+		want    uint64
+		wantOK  bool
+	}{
+		{"Padded", "aaaaab", 1, true},
+		{"Base62", "aaaaba", 62, true},
+		{"Shorter than length", "ba", 62, true},
+		{"Outside alphabet", "aa-aab", 0, false},
+		{"Empty", "", 0, false},
+		{"Overflows uint64", strings.Repeat("9", 12), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeCounter(tt.shortID, DefaultAlphabet)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DecodeCounter() got = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCounterGenerator_ResumesAfterDecodedID(t *testing.T) {
+	g, err := NewIDGenerator(StrategyCounter, DefaultAlphabet, 6, 0)
+	if err != nil {
+		t.Fatalf("NewIDGenerator() error = %v", err)
+	}
+
+	var last string
+	for i := 0; i < 100; i++ {
+		last = g.Generate("", 0)
+	}
+
+	start, ok := DecodeCounter(last, DefaultAlphabet)
+	if !ok {
+		t.Fatalf("DecodeCounter(%q) failed", last)
+	}
+
+	resumed, err := NewIDGenerator(StrategyCounter, DefaultAlphabet, 6, start)
+	if err != nil {
+		t.Fatalf("NewIDGenerator() error = %v", err)
+	}
+
+	if got, want := resumed.Generate("", 0), g.Generate("", 0); got != want {
+		t.Errorf("Generate() after resume got = %v, want %v", got, want)
+	}
+}
+
+func TestHashGenerator(t *testing.T) {
+	g, err := NewIDGenerator(StrategyHash, DefaultAlphabet, 6, 0)
+	if err != nil {
+		t.Fatalf("NewIDGenerator() error = %v", err)
+	}
+
+	first := g.Generate("https://example.com", 0)
+	if again := g.Generate("https://example.com", 0); again != first {
+		t.Errorf("Generate() is not deterministic: %v != %v", first, again)
+	}
+
+	if retry := g.Generate("https://example.com", 1); retry == first {
+		t.Errorf("Generate() must differ on retry, got %v", retry)
+	}
+}
+
+// sequenceGenerator возвращает заранее заданные ID по очереди.
+type sequenceGenerator struct {
+	ids []string
+	pos int
+}
+
+func (g *sequenceGenerator) Generate(originalURL string, attempt int) string {
+	id := g.ids[g.pos%len(g.ids)]
+	g.pos++
+	return id
+}
+
+func TestShortenURL_RetriesOnCollision(t *testing.T) {
+	ctx := context.Background()
+	mockStorage := NewMockStorage()
+	mockStorage.data["taken"] = "https://example.com/taken"
+
+	generator := &sequenceGenerator{ids: []string{"taken", "api", "free"}}
+	service := NewShortenerService(mockStorage, "", WithIDGenerator(generator), WithReservedAliases("api"))
+
+	shortID, err := service.ShortenURL(ctx, "https://example.com", "")
+	if err != nil {
+		t.Fatalf("ShortenURL() error = %v", err)
+	}
+
+	if shortID != "free" {
+		t.Errorf("ShortenURL() got = %v, want %v", shortID, "free")
+	}
+}
+
+func TestShortenURL_GiveUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	mockStorage := NewMockStorage()
+	mockStorage.data["taken"] = "https://example.com/taken"
+
+	service := NewShortenerService(mockStorage, "", WithIDGenerator(&sequenceGenerator{ids: []string{"taken"}}))
+
+	_, err := service.ShortenURL(ctx, "https://example.com", "")
+	if !errors.Is(err, ErrIDGenerationFailed) {
+		t.Errorf("ShortenURL() error = %v, want %v", err, ErrIDGenerationFailed)
+	}
+
+	if errors.Is(err, model.ErrShortURLExists) {
+		t.Errorf("ShortenURL() must not report alias conflict, got %v", err)
+	}
+}
@@ -3,11 +3,59 @@ package service
 
 import (
 	"context"
-	"math/rand"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/noedaka/go-url-shortener/internal/analytics"
+	"github.com/noedaka/go-url-shortener/internal/limits"
+	"github.com/noedaka/go-url-shortener/internal/logger"
 	"github.com/noedaka/go-url-shortener/internal/model"
 	"github.com/noedaka/go-url-shortener/internal/storage"
+	"github.com/noedaka/go-url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidAlias возвращается, если пользовательский алиас не соответствует формату.
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrReservedAlias возвращается, если пользовательский алиас совпадает с зарезервированным словом.
+	ErrReservedAlias = errors.New("alias is reserved")
+	// ErrInvalidExpiry возвращается, если срок действия сокращенного URL задан некорректно.
+	ErrInvalidExpiry = errors.New("invalid expiry")
+	// ErrIDGenerationFailed возвращается, если не удалось подобрать свободный сокращенный ID.
+	ErrIDGenerationFailed = errors.New("cannot generate unique short id")
+	// ErrURLNotFound возвращается, если у пользователя нет указанного сокращенного URL.
+	ErrURLNotFound = errors.New("url not found")
+	// ErrAnalyticsDisabled возвращается, если сбор статистики переходов не настроен.
+	ErrAnalyticsDisabled = errors.New("click analytics disabled")
+	// ErrInvalidURL возвращается, если сокращаемый URL некорректен или его схема не разрешена.
+	ErrInvalidURL = errors.New("invalid url")
+)
+
+// MaxTTLSeconds - наибольшее время жизни ссылки (100 лет). Ограничение не дает
+// переполнить time.Duration, максимум которого около 292 лет.
+const MaxTTLSeconds = 100 * 365 * 24 * 60 * 60
+
+// maxGenerateAttempts ограничивает число попыток генерации ID при коллизиях.
+const maxGenerateAttempts = 10
+
+// aliasPattern описывает допустимый формат пользовательского алиаса.
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// tracer создает span для операций сервиса.
+var tracer = otel.Tracer("github.com/noedaka/go-url-shortener/internal/service")
+
+// Атрибуты span операций сервиса.
+const (
+	attrShortID  = attribute.Key("shortener.short_id")
+	attrUserID   = attribute.Key("shortener.user_id")
+	attrURLCount = attribute.Key("shortener.url_count")
 )
 
 // ShortenerService реализует операции над URL.
@@ -16,25 +64,96 @@ type ShortenerService struct {
 	storage storage.URLStorage
 	// BaseURL представляет адрес используемый сервером приложения.
 	BaseURL string
-	rand    *rand.Rand
+	// generator генерирует сокращенные ID.
+	generator IDGenerator
+	// clicks записывает переходы по сокращенным URL.
+	clicks *analytics.Recorder
+	// limits ограничивает длину URL и размер пакетных запросов.
+	limits limits.Limits
+	// reserved содержит ID, совпадающие с маршрутами сервера.
+	reserved map[string]struct{}
+	// schemes содержит разрешенные схемы сокращаемых URL.
+	schemes map[string]struct{}
+}
+
+// Option настраивает ShortenerService.
+type Option func(*ShortenerService)
+
+// WithIDGenerator задает генератор сокращенных ID.
+func WithIDGenerator(generator IDGenerator) Option {
+	return func(s *ShortenerService) {
+		s.generator = generator
+	}
+}
+
+// WithClickRecorder включает сбор статистики переходов.
+func WithClickRecorder(recorder *analytics.Recorder) Option {
+	return func(s *ShortenerService) {
+		s.clicks = recorder
+	}
+}
+
+// WithLimits задает ограничения длины URL и размера пакетных запросов.
+func WithLimits(l limits.Limits) Option {
+	return func(s *ShortenerService) {
+		s.limits = l
+	}
+}
+
+// WithAllowedSchemes задает схемы, URL с которыми можно сокращать.
+// По умолчанию разрешены DefaultAllowedSchemes.
+func WithAllowedSchemes(schemes ...string) Option {
+	return func(s *ShortenerService) {
+		s.schemes = make(map[string]struct{}, len(schemes))
+		for _, scheme := range schemes {
+			s.schemes[strings.ToLower(scheme)] = struct{}{}
+		}
+	}
+}
+
+// WithReservedAliases запрещает выдавать ID, совпадающие с aliases без учета регистра.
+// Сюда передаются первые сегменты путей маршрутов сервера, которые иначе
+// перекрыли бы сокращенные ссылки.
+func WithReservedAliases(aliases ...string) Option {
+	return func(s *ShortenerService) {
+		s.reserved = make(map[string]struct{}, len(aliases))
+		for _, alias := range aliases {
+			s.reserved[strings.ToLower(alias)] = struct{}{}
+		}
+	}
 }
 
 // NewShortenerService создает новый экземпляр ShortenerService.
-func NewShortenerService(storage storage.URLStorage, baseURL string) *ShortenerService {
-	return &ShortenerService{
-		storage: storage,
-		BaseURL: baseURL,
-		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+//
+// По умолчанию используется генератор случайных ID длиной DefaultIDLength из алфавита base62.
+func NewShortenerService(storage storage.URLStorage, baseURL string, opts ...Option) *ShortenerService {
+	s := &ShortenerService{
+		storage:   storage,
+		BaseURL:   baseURL,
+		generator: &RandomGenerator{alphabet: DefaultAlphabet, length: DefaultIDLength},
+	}
+
+	WithAllowedSchemes(DefaultAllowedSchemes...)(s)
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // GetURL возвращает полный URL по его сокращенному ID.
-func (s *ShortenerService) GetURL(ctx context.Context, shortID string) (string, error) {
+func (s *ShortenerService) GetURL(ctx context.Context, shortID string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.GetURL", trace.WithAttributes(attrShortID.String(shortID)))
+	defer func() { tracing.End(span, err) }()
+
 	return s.storage.Get(ctx, shortID)
 }
 
 // GetURLByUser возращает все пары сокращенного URL и оригинального URL, когда либо сокращенные указанным пользователем.
-func (s *ShortenerService) GetURLByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
+func (s *ShortenerService) GetURLByUser(ctx context.Context, userID string) (_ []model.URLPair, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.GetURLByUser", trace.WithAttributes(attrUserID.String(userID)))
+	defer func() { tracing.End(span, err) }()
+
 	urlPairs, err := s.storage.GetByUser(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -45,8 +164,28 @@ func (s *ShortenerService) GetURLByUser(ctx context.Context, userID string) ([]m
 	return urlPairs, nil
 }
 
+// StreamURLsByUser передает в fn пары URL пользователя по одной.
+//
+// Обход прекращается при отмене контекста или ошибке fn, что позволяет
+// вызывающему ограничивать скорость чтения.
+func (s *ShortenerService) StreamURLsByUser(ctx context.Context, userID string, fn func(model.URLPair) error) (err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.StreamURLsByUser", trace.WithAttributes(attrUserID.String(userID)))
+	defer func() { tracing.End(span, err) }()
+
+	return storage.IterateByUser(ctx, s.storage, userID, func(pair model.URLPair) error {
+		pair.ShortURL = s.BaseURL + "/" + pair.ShortURL
+		return fn(pair)
+	})
+}
+
 // DeleteShortURLSByUser удаляет сокращенные URL указанного пользователя.
-func (s *ShortenerService) DeleteShortURLSByUser(ctx context.Context, userID string, shortURL []string) error {
+func (s *ShortenerService) DeleteShortURLSByUser(ctx context.Context, userID string, shortURL []string) (err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.DeleteShortURLSByUser", trace.WithAttributes(
+		attrUserID.String(userID),
+		attrURLCount.Int(len(shortURL)),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	if len(shortURL) == 0 {
 		return nil
 	}
@@ -59,21 +198,107 @@ func (s *ShortenerService) DeleteShortURLSByUser(ctx context.Context, userID str
 
 // ShortenURL создает сокращенный URL и сохраняет его в хранилище указанного пользователя.
 func (s *ShortenerService) ShortenURL(ctx context.Context, originalURL, userID string) (string, error) {
-	shortID := s.generateShortID()
-	err := s.storage.Save(ctx, shortID, originalURL, userID)
+	return s.ShortenURLWithOptions(ctx, originalURL, userID, model.ShortenOptions{})
+}
 
+// ShortenURLWithOptions создает сокращенный URL с учетом дополнительных параметров.
+//
+// URL проверяется и сохраняется в канонической форме, поэтому один и тот же адрес,
+// записанный по-разному, считается одним URL. Если задан алиас, он используется
+// вместо сгенерированного ID после проверки формата. Срок действия задается
+// либо абсолютным моментом, либо временем жизни в секундах.
+func (s *ShortenerService) ShortenURLWithOptions(ctx context.Context, originalURL, userID string, opts model.ShortenOptions) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.ShortenURL", trace.WithAttributes(attrUserID.String(userID)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.limits.CheckURL(originalURL); err != nil {
+		return "", err
+	}
+
+	originalURL, err = normalizeURL(originalURL, s.schemes)
 	if err != nil {
 		return "", err
 	}
 
-	return shortID, nil
+	// Punycode может удлинить URL, поэтому ограничение проверяется и для канонической формы.
+	if err := s.limits.CheckURL(originalURL); err != nil {
+		return "", err
+	}
+
+	expiresAt, err := resolveExpiry(opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Alias != "" {
+		if err := s.validateAlias(opts.Alias); err != nil {
+			return "", err
+		}
+
+		if err := s.storage.Save(ctx, opts.Alias, originalURL, userID, expiresAt); err != nil {
+			return "", err
+		}
+
+		return opts.Alias, nil
+	}
+
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		shortID := s.generator.Generate(originalURL, attempt)
+		if s.isReserved(shortID) {
+			continue
+		}
+
+		err := s.storage.Save(ctx, shortID, originalURL, userID, expiresAt)
+		if errors.Is(err, model.ErrShortURLExists) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		return shortID, nil
+	}
+
+	return "", fmt.Errorf("%w after %d attempts", ErrIDGenerationFailed, maxGenerateAttempts)
+}
+
+// CanonicalURL возвращает каноническую форму URL, в которой он сохраняется в хранилище.
+func (s *ShortenerService) CanonicalURL(rawURL string) (string, error) {
+	return normalizeURL(rawURL, s.schemes)
 }
 
 // ShortenMultipleURLS создает сокращенные URL для слайса URL.
-func (s *ShortenerService) ShortenMultipleURLS(ctx context.Context, batchRequest []model.BatchRequest, userID string) ([]model.BatchResponse, error) {
+//
+// Пакет, превышающий ограничение числа URL или содержащий некорректный URL,
+// алиас или срок действия, отклоняется целиком до сохранения первого URL.
+func (s *ShortenerService) ShortenMultipleURLS(ctx context.Context, batchRequest []model.BatchRequest, userID string) (_ []model.BatchResponse, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.ShortenMultipleURLS", trace.WithAttributes(
+		attrUserID.String(userID),
+		attrURLCount.Int(len(batchRequest)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.limits.CheckBatch(len(batchRequest)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	aliases := make(map[string]struct{}, len(batchRequest))
+	for _, request := range batchRequest {
+		if err := s.validateBatchRequest(request, aliases, now); err != nil {
+			return nil, fmt.Errorf("correlation id %q: %w", request.CorrelationID, err)
+		}
+	}
+
 	var batchResponse []model.BatchResponse
 	for _, request := range batchRequest {
-		shortURL, err := s.ShortenURL(ctx, request.URL, userID)
+		opts := model.ShortenOptions{
+			Alias:      request.Alias,
+			ExpiresAt:  request.ExpiresAt,
+			TTLSeconds: request.TTLSeconds,
+		}
+		shortURL, err := s.ShortenURLWithOptions(ctx, request.URL, userID, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -89,15 +314,149 @@ func (s *ShortenerService) ShortenMultipleURLS(ctx context.Context, batchRequest
 	return batchResponse, nil
 }
 
-func (s *ShortenerService) GetStats(ctx context.Context) (*model.Stats, error) {
+func (s *ShortenerService) GetStats(ctx context.Context) (_ *model.Stats, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.GetStats")
+	defer func() { tracing.End(span, err) }()
+
 	return s.storage.GetStats(ctx)
 }
 
-func (s *ShortenerService) generateShortID() string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, 6)
-	for i := range b {
-		b[i] = charset[s.rand.Intn(len(charset))]
+// Ping проверяет доступность хранилища.
+func (s *ShortenerService) Ping(ctx context.Context) error {
+	return s.storage.Ping(ctx)
+}
+
+// RecordClick асинхронно записывает переход по сокращенному URL.
+//
+// Если сбор статистики не настроен, переход игнорируется.
+func (s *ShortenerService) RecordClick(click model.Click) {
+	if s.clicks == nil {
+		return
+	}
+
+	s.clicks.Record(click)
+}
+
+// GetClickStats возвращает статистику переходов по сокращенному URL указанного пользователя.
+func (s *ShortenerService) GetClickStats(ctx context.Context, userID, shortID string) (_ *model.ClickStats, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.GetClickStats", trace.WithAttributes(
+		attrUserID.String(userID),
+		attrShortID.String(shortID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if s.clicks == nil {
+		return nil, ErrAnalyticsDisabled
 	}
-	return string(b)
+
+	urlPairs, err := s.storage.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pair := range urlPairs {
+		if pair.ShortURL == shortID {
+			return s.clicks.Stats(ctx, shortID)
+		}
+	}
+
+	return nil, ErrURLNotFound
+}
+
+// PurgeExpired периодически помечает в хранилище удаленными сокращенные URL с истекшим сроком действия.
+//
+// Блокируется до отмены контекста.
+func (s *ShortenerService) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.storage.DeleteExpired(ctx)
+			if err != nil {
+				logger.Log.Error("failed to purge expired urls", zap.Error(err))
+				continue
+			}
+
+			if deleted > 0 {
+				logger.Log.Info("expired urls purged", zap.Int64("count", deleted))
+			}
+		}
+	}
+}
+
+func resolveExpiry(opts model.ShortenOptions, now time.Time) (*time.Time, error) {
+	switch {
+	case opts.ExpiresAt != nil && opts.TTLSeconds != 0:
+		return nil, fmt.Errorf("%w: expires_at and ttl_seconds are mutually exclusive", ErrInvalidExpiry)
+	case opts.TTLSeconds < 0:
+		return nil, fmt.Errorf("%w: ttl_seconds must be positive", ErrInvalidExpiry)
+	case opts.TTLSeconds > MaxTTLSeconds:
+		return nil, fmt.Errorf("%w: ttl_seconds must not exceed %d", ErrInvalidExpiry, MaxTTLSeconds)
+	case opts.TTLSeconds > 0:
+		expiresAt := now.Add(time.Duration(opts.TTLSeconds) * time.Second)
+		return &expiresAt, nil
+	case opts.ExpiresAt != nil:
+		if !opts.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidExpiry)
+		}
+		return opts.ExpiresAt, nil
+	}
+
+	return nil, nil
+}
+
+// validateBatchRequest проверяет URL и параметры элемента пакета так же, как
+// ShortenURLWithOptions, и запоминает его алиас в aliases, чтобы отклонить повтор.
+func (s *ShortenerService) validateBatchRequest(request model.BatchRequest, aliases map[string]struct{}, now time.Time) error {
+	if err := s.limits.CheckURL(request.URL); err != nil {
+		return err
+	}
+
+	if _, err := normalizeURL(request.URL, s.schemes); err != nil {
+		return err
+	}
+
+	opts := model.ShortenOptions{
+		ExpiresAt:  request.ExpiresAt,
+		TTLSeconds: request.TTLSeconds,
+	}
+	if _, err := resolveExpiry(opts, now); err != nil {
+		return err
+	}
+
+	if request.Alias == "" {
+		return nil
+	}
+
+	if err := s.validateAlias(request.Alias); err != nil {
+		return err
+	}
+
+	if _, exists := aliases[request.Alias]; exists {
+		return fmt.Errorf("%w: %q is used more than once in the batch", ErrInvalidAlias, request.Alias)
+	}
+	aliases[request.Alias] = struct{}{}
+
+	return nil
+}
+
+func (s *ShortenerService) validateAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: %q", ErrInvalidAlias, alias)
+	}
+
+	if s.isReserved(alias) {
+		return fmt.Errorf("%w: %q", ErrReservedAlias, alias)
+	}
+
+	return nil
+}
+
+func (s *ShortenerService) isReserved(shortID string) bool {
+	_, reserved := s.reserved[strings.ToLower(shortID)]
+	return reserved
 }
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/noedaka/go-url-shortener/internal/model"
 )
@@ -19,7 +22,10 @@ func NewMockStorage() *MockStorage {
 	}
 }
 
-func (m *MockStorage) Save(ctx context.Context, shortURL, originalURL, userID string) error {
+func (m *MockStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	if _, exists := m.data[shortURL]; exists {
+		return model.ErrShortURLExists
+	}
 	m.data[shortURL] = originalURL
 	return nil
 }
@@ -43,6 +49,14 @@ func (m *MockStorage) GetStats(ctx context.Context) (*model.Stats, error) {
 	return nil, nil
 }
 
+func (m *MockStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
 func TestShortenerService(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -50,7 +64,7 @@ func TestShortenerService(t *testing.T) {
 		wantErr bool
 	}{
 		{"Valid URL", "https://example.com", false},
-		{"Empty URL", "", false},
+		{"Empty URL", "", true},
 	}
 
 	for _, tt := range tests {
@@ -92,6 +106,91 @@ func TestGetURL_NotFound(t *testing.T) {
 	}
 }
 
+func TestShortenURLWithAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{"Valid alias", "q4-report", nil},
+		{"Too short", "ab", ErrInvalidAlias},
+		{"Invalid characters", "q4/report", ErrInvalidAlias},
+		{"Reserved word", "API", ErrReservedAlias},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service := NewShortenerService(NewMockStorage(), "", WithReservedAliases("api"))
+
+			opts := model.ShortenOptions{Alias: tt.alias}
+			shortID, err := service.ShortenURLWithOptions(ctx, "https://example.com", "", opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ShortenURLWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && shortID != tt.alias {
+				t.Errorf("ShortenURLWithOptions() got = %v, want %v", shortID, tt.alias)
+			}
+		})
+	}
+}
+
+func TestShortenURLWithAlias_Collision(t *testing.T) {
+	ctx := context.Background()
+	service := NewShortenerService(NewMockStorage(), "")
+	opts := model.ShortenOptions{Alias: "q4-report"}
+
+	if _, err := service.ShortenURLWithOptions(ctx, "https://example.com/1", "", opts); err != nil {
+		t.Fatalf("ShortenURLWithOptions() error = %v", err)
+	}
+
+	_, err := service.ShortenURLWithOptions(ctx, "https://example.com/2", "", opts)
+	if !errors.Is(err, model.ErrShortURLExists) {
+		t.Errorf("ShortenURLWithOptions() error = %v, want %v", err, model.ErrShortURLExists)
+	}
+}
+
+func TestResolveExpiry(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		opts    model.ShortenOptions
+		want    *time.Time
+		wantErr error
+	}{
+		{"No expiry", model.ShortenOptions{}, nil, nil},
+		{"TTL", model.ShortenOptions{TTLSeconds: 60}, ptrTime(now.Add(time.Minute)), nil},
+		{"Absolute", model.ShortenOptions{ExpiresAt: &future}, &future, nil},
+		{"Past", model.ShortenOptions{ExpiresAt: &past}, nil, ErrInvalidExpiry},
+		{"Negative TTL", model.ShortenOptions{TTLSeconds: -1}, nil, ErrInvalidExpiry},
+		{"Max TTL", model.ShortenOptions{TTLSeconds: MaxTTLSeconds}, ptrTime(now.Add(MaxTTLSeconds * time.Second)), nil},
+		{"TTL above max", model.ShortenOptions{TTLSeconds: MaxTTLSeconds + 1}, nil, ErrInvalidExpiry},
+		{"TTL overflows duration", model.ShortenOptions{TTLSeconds: math.MaxInt64 / 1000}, nil, ErrInvalidExpiry},
+		{"Both", model.ShortenOptions{ExpiresAt: &future, TTLSeconds: 60}, nil, ErrInvalidExpiry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveExpiry(tt.opts, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("resolveExpiry() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
 type URLNotFoundError struct {
 	ShortURL string
 }
@@ -206,6 +305,14 @@ func (m *FakeStorageWithUserData) GetStats(ctx context.Context) (*model.Stats, e
 	return nil, nil
 }
 
+func (m *FakeStorageWithUserData) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *FakeStorageWithUserData) Ping(ctx context.Context) error {
+	return nil
+}
+
 func NewFakeStorageWithUserData() *FakeStorageWithUserData {
 	return &FakeStorageWithUserData{
 		data:     make(map[string]string),
@@ -214,7 +321,7 @@ func NewFakeStorageWithUserData() *FakeStorageWithUserData {
 	}
 }
 
-func (m *FakeStorageWithUserData) Save(ctx context.Context, shortURL, originalURL, userID string) error {
+func (m *FakeStorageWithUserData) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
 	m.data[shortURL] = originalURL
 	if userID != "" {
 		m.userURLs[userID] = append(m.userURLs[userID], model.URLPair{
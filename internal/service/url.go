@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultAllowedSchemes - схемы сокращаемых URL по умолчанию.
+var DefaultAllowedSchemes = []string{"http", "https"}
+
+// defaultPorts содержит порты по умолчанию, которые убираются из URL.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// normalizeURL проверяет сокращаемый URL и приводит его к канонической форме,
+// чтобы одинаковые адреса, записанные по-разному, сохранялись один раз.
+//
+// URL должен быть абсолютным, содержать хост и схему из schemes и не содержать
+// учетных данных: сокращенный URL раскрыл бы их любому, кто по нему перейдет.
+// При нормализации схема и хост приводятся к нижнему регистру, интернационализированные домены
+// переводятся в punycode, порт по умолчанию для схемы убирается, а в пути, запросе
+// и фрагменте незарезервированные символы декодируются, остальные
+// percent-последовательности записываются в верхнем регистре.
+//
+// URL, сохраненные до появления нормализации, хранятся в исходном виде и не
+// совпадают с канонической формой: повторное сокращение такого адреса создаст
+// новую запись вместо конфликта с существующей.
+func normalizeURL(rawURL string, schemes map[string]struct{}) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("%w: url is empty", ErrInvalidURL)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidURL, unwrapURLError(err))
+	}
+
+	if u.Scheme == "" {
+		return "", fmt.Errorf("%w: url must be absolute", ErrInvalidURL)
+	}
+	if _, ok := schemes[u.Scheme]; !ok {
+		return "", fmt.Errorf("%w: scheme %q is not allowed", ErrInvalidURL, u.Scheme)
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", fmt.Errorf("%w: url must contain a host", ErrInvalidURL)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("%w: url must not contain user info", ErrInvalidURL)
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("%w: invalid port %q", ErrInvalidURL, port)
+		}
+		if defaultPorts[u.Scheme] == port {
+			port = ""
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(u.Scheme)
+	b.WriteString("://")
+	b.WriteString(host)
+	if port != "" {
+		b.WriteByte(':')
+		b.WriteString(port)
+	}
+
+	path, err := normalizeEscapes(u.EscapedPath())
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(path)
+
+	if u.ForceQuery || u.RawQuery != "" {
+		query, err := normalizeEscapes(u.RawQuery)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+
+	if u.Fragment != "" {
+		fragment, err := normalizeEscapes(u.EscapedFragment())
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte('#')
+		b.WriteString(fragment)
+	}
+
+	return b.String(), nil
+}
+
+// normalizeHost приводит доменное имя к нижнему регистру и punycode,
+// а IP-адрес - к каноническому виду.
+func normalizeHost(host string) (string, error) {
+	if host == "" {
+		return "", fmt.Errorf("%w: url must contain a host", ErrInvalidURL)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return "[" + ip.String() + "]", nil
+		}
+		return ip.String(), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host %q", ErrInvalidURL, host)
+	}
+
+	return ascii, nil
+}
+
+// normalizeEscapes нормализует percent-кодирование по RFC 3986:
+// декодирует незарезервированные символы, записывает остальные
+// последовательности в верхнем регистре и кодирует недопустимые в URL байты.
+func normalizeEscapes(s string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return "", fmt.Errorf("%w: invalid percent-encoding", ErrInvalidURL)
+			}
+			decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				b.WriteByte('%')
+				b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+			}
+			i += 2
+		case isUnreserved(c) || strings.IndexByte(reservedChars, c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// reservedChars - зарезервированные символы RFC 3986, допустимые в URL без кодирования.
+const reservedChars = ":/?#[]@!$&'()*+,;="
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+// unwrapURLError убирает из ошибки url.Parse повтор исходного URL.
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
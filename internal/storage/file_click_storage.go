@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+)
+
+// clicksFileSuffix добавляется к пути файла хранилища для хранения переходов.
+const clicksFileSuffix = ".clicks"
+
+// maxClickLineSize ограничивает длину строки файла переходов: снимок популярного URL
+// содержит всех его уникальных посетителей.
+const maxClickLineSize = 64 << 20
+
+// HourlyClickWindow - период, за который строится почасовая статистика переходов.
+// FileStorage не хранит почасовые интервалы старше этого периода.
+const HourlyClickWindow = 24 * time.Hour
+
+// clickAggregate - статистика переходов по сокращенному URL, которую FileStorage
+// хранит в памяти вместо самих переходов.
+type clickAggregate struct {
+	total    int
+	visitors map[string]struct{}
+	hourly   map[time.Time]int
+	daily    map[time.Time]int
+	// lastHour - начало самого позднего почасового интервала.
+	lastHour time.Time
+}
+
+func newClickAggregate() *clickAggregate {
+	return &clickAggregate{
+		visitors: make(map[string]struct{}),
+		hourly:   make(map[time.Time]int),
+		daily:    make(map[time.Time]int),
+	}
+}
+
+func (a *clickAggregate) add(click model.Click) {
+	ts := click.Timestamp.UTC()
+
+	hour := ts.Truncate(time.Hour)
+
+	a.total++
+	a.visitors[click.VisitorID] = struct{}{}
+	a.hourly[hour]++
+	a.daily[time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)]++
+	a.pruneHourly(hour)
+}
+
+func (a *clickAggregate) merge(s *clickSnapshot) {
+	a.total += s.Total
+	for _, visitor := range s.Visitors {
+		a.visitors[visitor] = struct{}{}
+	}
+	for start, clicks := range s.Hourly {
+		a.hourly[start] += clicks
+		a.pruneHourly(start)
+	}
+	for start, clicks := range s.Daily {
+		a.daily[start] += clicks
+	}
+}
+
+// pruneHourly удаляет почасовые интервалы, начавшиеся раньше чем за HourlyClickWindow
+// до самого позднего из них. Время переходов задает сервер, поэтому самый поздний
+// интервал не позже текущего и удаляются только интервалы, которые уже не попадут в статистику.
+func (a *clickAggregate) pruneHourly(hour time.Time) {
+	if !hour.After(a.lastHour) {
+		// Запоздавший переход не должен вернуть уже удаленный интервал.
+		if hour.Before(a.lastHour.Add(-HourlyClickWindow)) {
+			delete(a.hourly, hour)
+		}
+		return
+	}
+	a.lastHour = hour
+
+	since := hour.Add(-HourlyClickWindow)
+	for start := range a.hourly {
+		if start.Before(since) {
+			delete(a.hourly, start)
+		}
+	}
+}
+
+// clickSnapshot - запись файла переходов, которой уплотнение заменяет
+// все переходы по одному сокращенному URL.
+type clickSnapshot struct {
+	ShortURL string            `json:"short_url"`
+	Total    int               `json:"total"`
+	Visitors []string          `json:"visitors"`
+	Hourly   map[time.Time]int `json:"hourly"`
+	Daily    map[time.Time]int `json:"daily"`
+}
+
+// clickLine - строка файла переходов: отдельный переход или снимок после уплотнения.
+type clickLine struct {
+	model.Click
+	Snapshot *clickSnapshot `json:"snapshot,omitempty"`
+}
+
+// snapshotLine - строка файла переходов со снимком.
+type snapshotLine struct {
+	Snapshot *clickSnapshot `json:"snapshot"`
+}
+
+// SaveClicks дописывает переходы в файл в формате JSON Lines, сбрасывает их на диск
+// и добавляет в статистику в памяти.
+//
+// Переходы по удаленным URL пропускаются: они могли попасть в очередь до удаления.
+func (fs *FileStorage) SaveClicks(ctx context.Context, clicks []model.Click) error {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	clicks = fs.liveClicks(clicks)
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	fs.clicksMu.Lock()
+	defer fs.clicksMu.Unlock()
+
+	file, err := os.OpenFile(fs.clicksPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, click := range clicks {
+		if err := encoder.Encode(click); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	for _, click := range clicks {
+		fs.addClick(click)
+	}
+
+	return nil
+}
+
+// GetClickStats возвращает статистику переходов по сокращенному URL.
+func (fs *FileStorage) GetClickStats(ctx context.Context, shortURL string, hourlySince, dailySince time.Time) (*model.ClickStats, error) {
+	fs.clicksMu.Lock()
+	defer fs.clicksMu.Unlock()
+
+	stats := &model.ClickStats{
+		Hourly: []model.ClickBucket{},
+		Daily:  []model.ClickBucket{},
+	}
+
+	a, exists := fs.clicks[shortURL]
+	if !exists {
+		return stats, nil
+	}
+
+	stats.TotalClicks = a.total
+	stats.UniqueVisitors = len(a.visitors)
+	stats.Hourly = sortedBuckets(a.hourly, hourlySince)
+	stats.Daily = sortedBuckets(a.daily, dailySince)
+
+	return stats, nil
+}
+
+func (fs *FileStorage) clicksPath() string {
+	return fs.filePath + clicksFileSuffix
+}
+
+// liveClicks возвращает переходы clicks, кроме переходов по удаленным URL. Вызывается под fs.mu.
+func (fs *FileStorage) liveClicks(clicks []model.Click) []model.Click {
+	live := clicks[:0:0]
+	for _, click := range clicks {
+		if e, exists := fs.entries[click.ShortURL]; exists && e.deleted {
+			continue
+		}
+		live = append(live, click)
+	}
+
+	return live
+}
+
+// addClick добавляет переход в статистику в памяти. Вызывается под fs.clicksMu.
+func (fs *FileStorage) addClick(click model.Click) {
+	a, exists := fs.clicks[click.ShortURL]
+	if !exists {
+		a = newClickAggregate()
+		fs.clicks[click.ShortURL] = a
+	}
+
+	a.add(click)
+}
+
+// dropClicks забывает переходы по удаленному сокращенному URL. Вызывается под fs.mu.
+//
+// Строки файла переходов остаются до уплотнения и пропускаются при загрузке.
+func (fs *FileStorage) dropClicks(shortURL string) {
+	fs.clicksMu.Lock()
+	defer fs.clicksMu.Unlock()
+
+	delete(fs.clicks, shortURL)
+}
+
+// loadClicks загружает переходы из файла. Вызывается после loadData,
+// чтобы пропустить переходы по удаленным URL.
+func (fs *FileStorage) loadClicks() error {
+	file, err := os.Open(fs.clicksPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxClickLineSize)
+	for scanner.Scan() {
+		var line clickLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// Пропускаем поврежденные строки, например недописанную последнюю.
+			continue
+		}
+
+		if line.Snapshot != nil {
+			line.ShortURL = line.Snapshot.ShortURL
+		}
+		if e, exists := fs.entries[line.ShortURL]; exists && e.deleted {
+			continue
+		}
+
+		if line.Snapshot == nil {
+			fs.addClick(line.Click)
+			continue
+		}
+
+		a, exists := fs.clicks[line.ShortURL]
+		if !exists {
+			a = newClickAggregate()
+			fs.clicks[line.ShortURL] = a
+		}
+		a.merge(line.Snapshot)
+	}
+
+	return scanner.Err()
+}
+
+// compactClicks переписывает файл переходов, заменяя переходы по каждому URL
+// одним снимком статистики. Вызывается под fs.mu после удаления переходов по удаленным URL.
+func (fs *FileStorage) compactClicks() error {
+	fs.clicksMu.Lock()
+	defer fs.clicksMu.Unlock()
+
+	snapshots := make([]snapshotLine, 0, len(fs.clicks))
+	for shortURL, a := range fs.clicks {
+		visitors := make([]string, 0, len(a.visitors))
+		for visitor := range a.visitors {
+			visitors = append(visitors, visitor)
+		}
+
+		snapshots = append(snapshots, snapshotLine{Snapshot: &clickSnapshot{
+			ShortURL: shortURL,
+			Total:    a.total,
+			Visitors: visitors,
+			Hourly:   a.hourly,
+			Daily:    a.daily,
+		}})
+	}
+
+	return writeFileAtomic(fs.clicksPath(), snapshots)
+}
+
+// sortedBuckets возвращает отсортированные по времени интервалы, начинающиеся не раньше since.
+func sortedBuckets(counts map[time.Time]int, since time.Time) []model.ClickBucket {
+	buckets := make([]model.ClickBucket, 0, len(counts))
+	for start, clicks := range counts {
+		if start.Before(since) {
+			continue
+		}
+		buckets = append(buckets, model.ClickBucket{Start: start, Clicks: clicks})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+
+	return buckets
+}
@@ -1,182 +1,565 @@
 package storage
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/noedaka/go-url-shortener/internal/model"
 )
 
-// FileStorage реализует Storage интерфейс используя in-memory хранилище.
+// lockFileSuffix добавляется к пути файла хранилища для файла блокировки.
+const lockFileSuffix = ".lock"
+
+// ErrStorageLocked возвращается, если файл хранилища уже используется другим процессом.
+var ErrStorageLocked = errors.New("file storage is locked by another process")
+
+// ErrCorruptRecord возвращается при загрузке, если в середине файла хранилища есть
+// поврежденная запись. Такой файл нужно исправить вручную: отбросить запись
+// вместе со следующими за ней нельзя без потери данных.
+var ErrCorruptRecord = errors.New("corrupt record in file storage")
+
+// ErrStorageClosed возвращается Ping после закрытия хранилища.
+var ErrStorageClosed = errors.New("file storage is closed")
+
+// FileStorage реализует Storage интерфейс, храня данные в памяти
+// и дописывая каждое изменение в файл в формате JSON Lines.
+//
+// Все изменения файла выполняются под эксклюзивной блокировкой mu, поэтому
+// запись из разных горутин последовательна. От одновременной работы второго
+// процесса с тем же файлом защищает рекомендательная блокировка (flock)
+// файла с суффиксом .lock, удерживаемая до вызова Close.
 type FileStorage struct {
 	filePath string
+	lock     *os.File
 	mu       sync.RWMutex
-	urls     map[string]string
+	entries  map[string]*entry
+	// userIndex содержит сокращенные URL каждого пользователя в порядке сохранения.
+	userIndex map[string][]string
+
+	clicksMu sync.Mutex
+	// clicks содержит статистику переходов по каждому неудаленному сокращенному URL.
+	clicks map[string]*clickAggregate
+}
+
+// entry - состояние сокращенного URL в памяти.
+type entry struct {
+	originalURL string
+	userID      string
+	expiresAt   *time.Time
+	deleted     bool
 }
 
 type record struct {
-	UUID        string `json:"uuid"`
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
-	UserID      string `json:"user_id"`
+	UUID        string     `json:"uuid"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	UserID      string     `json:"user_id"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// IsDeleted помечает запись-надгробие, удаляющую ранее сохраненный URL пользователя,
+	// или, вместе с OriginalURL, удаленный URL, переписанный уплотнением.
+	IsDeleted bool `json:"is_deleted,omitempty"`
 }
 
-// NewPostgresStorage создает новый экземпляр FileStorage.
-func NewFileStorage(filePath string) *FileStorage {
+// NewFileStorage создает новый экземпляр FileStorage и загружает данные из файла.
+//
+// Возвращает ErrStorageLocked, если файл уже открыт другим экземпляром хранилища.
+// Файл в прежнем формате JSON-массива автоматически преобразуется в JSON Lines.
+func NewFileStorage(filePath string) (*FileStorage, error) {
+	lock, err := os.OpenFile(filePath+lockFileSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+
 	fs := &FileStorage{
-		filePath: filePath,
-		urls:     make(map[string]string),
+		filePath:  filePath,
+		lock:      lock,
+		entries:   make(map[string]*entry),
+		userIndex: make(map[string][]string),
+		clicks:    make(map[string]*clickAggregate),
+	}
+
+	if err := fs.loadData(); err != nil {
+		fs.Close()
+		return nil, err
+	}
+
+	if err := fs.loadClicks(); err != nil {
+		fs.Close()
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+// Close снимает блокировку файла хранилища.
+func (fs *FileStorage) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.lock == nil {
+		return nil
+	}
+
+	err := errors.Join(unlockFile(fs.lock), fs.lock.Close())
+	fs.lock = nil
+
+	return err
+}
+
+// Ping проверяет, что хранилище не закрыто и файл данных открывается на запись.
+func (fs *FileStorage) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	if fs.lock == nil {
+		return ErrStorageClosed
 	}
 
-	data, err := fs.loadData()
-	if err == nil {
-		fs.urls = data
+	file, err := os.OpenFile(fs.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
 	}
 
-	return fs
+	return file.Close()
 }
 
 // Save сохраняет сокращенный URL и оригинальный URL в хранилище указанного пользователя.
-func (fs *FileStorage) Save(ctx context.Context, shortURL, originalURL, userID string) error {
+func (fs *FileStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.entries[shortURL]; exists {
+		return model.ErrShortURLExists
+	}
+
 	record := record{
 		UUID:        uuid.New().String(),
 		ShortURL:    shortURL,
 		OriginalURL: originalURL,
 		UserID:      userID,
+		ExpiresAt:   expiresAt,
 	}
 
 	if err := fs.appendRecord(record); err != nil {
 		return err
 	}
 
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
-	fs.urls[shortURL] = originalURL
+	fs.apply(record)
 
 	return nil
 }
 
 // Get возращает оригинальный URL по сокращенному.
 func (fs *FileStorage) Get(ctx context.Context, shortURL string) (string, error) {
+	entry, err := fs.GetEntry(ctx, shortURL)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.Resolve(time.Now())
+}
+
+// GetEntry возвращает запись о сокращенном URL вместе с признаком удаления и сроком действия.
+func (fs *FileStorage) GetEntry(ctx context.Context, shortURL string) (*model.URLEntry, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	if url, exists := fs.urls[shortURL]; exists {
-		return url, nil
+	e, exists := fs.entries[shortURL]
+	if !exists {
+		return nil, model.ErrShortURLNotFound
 	}
-	return "", errors.New("URL not found")
+
+	return &model.URLEntry{
+		OriginalURL: e.originalURL,
+		ExpiresAt:   e.expiresAt,
+		IsDeleted:   e.deleted,
+	}, nil
 }
 
-// GetByUser возвращает все пары URL когда либо сокращенных указанным пользователем.
+// GetByUser возвращает пары URL, сокращенные указанным пользователем, включая удаленные,
+// кроме истекших. Так же, как PostgresStorage.
 func (fs *FileStorage) GetByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	records, err := fs.readAll()
-	if err != nil {
-		return nil, err
-	}
-
+	now := time.Now()
 	var urlPairs []model.URLPair
-	for _, record := range records {
-		if record.UserID == userID {
-			pair := model.URLPair{
-				ShortURL:    record.ShortURL,
-				OriginalURL: record.OriginalURL,
-			}
-			urlPairs = append(urlPairs, pair)
+	for _, shortURL := range fs.userIndex[userID] {
+		e := fs.entries[shortURL]
+		if e.expiresAt != nil && !e.expiresAt.After(now) {
+			continue
 		}
+
+		urlPairs = append(urlPairs, model.URLPair{
+			ShortURL:    shortURL,
+			OriginalURL: e.originalURL,
+		})
 	}
 
 	return urlPairs, nil
 }
 
-// DeleteByUser - заглушка, так как для in-memory не нужна.
+// IterateShortIDs вызывает fn для каждого сокращенного ID, включая удаленные и истекшие.
+func (fs *FileStorage) IterateShortIDs(ctx context.Context, fn func(shortID string) error) error {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	for shortURL := range fs.entries {
+		if err := fn(shortURL); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// DeleteByUser помечает удаленными сокращенные URL указанного пользователя.
+//
+// URL других пользователей и уже удаленные URL пропускаются. Для каждого удаления
+// в файл дописывается запись-надгробие, которая учитывается при следующей загрузке.
 func (fs *FileStorage) DeleteByUser(ctx context.Context, userID string, shortURL []string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var tombstones []record
+	for _, short := range shortURL {
+		e, exists := fs.entries[short]
+		if !exists || e.userID != userID || e.deleted {
+			continue
+		}
+
+		tombstones = append(tombstones, record{
+			UUID:      uuid.New().String(),
+			ShortURL:  short,
+			UserID:    userID,
+			IsDeleted: true,
+		})
+	}
+
+	if len(tombstones) == 0 {
+		return nil
+	}
+
+	if err := fs.appendRecord(tombstones...); err != nil {
+		return err
+	}
+
+	for _, tombstone := range tombstones {
+		fs.apply(tombstone)
+	}
+
 	return nil
 }
 
-func (fs *FileStorage) loadData() (map[string]string, error) {
-	records, err := fs.readAll()
-	if err != nil {
-		return nil, err
+// DeleteExpired помечает удаленными сокращенные URL с истекшим сроком действия.
+//
+// URL остаются в памяти, чтобы их ID не выдавались повторно, а переходы по ним
+// по-прежнему получали 410 Gone. Файл не изменяется: срок действия хранится
+// в записи и учитывается при следующей загрузке.
+func (fs *FileStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.markExpired(time.Now()), nil
+}
+
+// Compact переписывает файл, оставляя по одной записи на каждый сокращенный URL,
+// а файл переходов - заменяя переходы по каждому URL одним снимком статистики.
+//
+// Удаленные и истекшие URL записываются одной записью с признаком is_deleted:
+// после уплотнения переходы по ним по-прежнему получают 410 Gone, а их ID не
+// выдаются повторно. Новый файл записывается рядом с текущим и атомарно подменяет его.
+func (fs *FileStorage) Compact(ctx context.Context) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.markExpired(time.Now())
+
+	records := make([]record, 0, len(fs.entries))
+	for _, shortURLs := range fs.userIndex {
+		for _, shortURL := range shortURLs {
+			e := fs.entries[shortURL]
+			records = append(records, record{
+				UUID:        uuid.New().String(),
+				ShortURL:    shortURL,
+				OriginalURL: e.originalURL,
+				UserID:      e.userID,
+				ExpiresAt:   e.expiresAt,
+				IsDeleted:   e.deleted,
+			})
+		}
 	}
 
-	result := make(map[string]string)
-	for _, record := range records {
-		result[record.ShortURL] = record.OriginalURL
+	if err := fs.rewrite(records); err != nil {
+		return err
+	}
+
+	return fs.compactClicks()
+}
+
+// GetStats возвращает количество сокращенных URL и количество пользователей, которым они принадлежат.
+//
+// Как и в PostgresStorage, учитываются все сохраненные URL, включая удаленные и истекшие.
+func (fs *FileStorage) GetStats(ctx context.Context) (*model.Stats, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	return &model.Stats{
+		URLs:  len(fs.entries),
+		Users: len(fs.userIndex),
+	}, nil
+}
+
+// apply применяет запись к состоянию в памяти. Вызывается под fs.mu.
+//
+// Запись с признаком IsDeleted без оригинального URL - надгробие, удаляющее
+// ранее сохраненный URL. Запись с признаком и оригинальным URL пишется
+// уплотнением и сохраняет удаленный URL целиком.
+func (fs *FileStorage) apply(r record) {
+	if r.IsDeleted && r.OriginalURL != "" {
+		if _, exists := fs.entries[r.ShortURL]; exists {
+			return
+		}
+
+		fs.entries[r.ShortURL] = &entry{
+			originalURL: r.OriginalURL,
+			userID:      r.UserID,
+			expiresAt:   r.ExpiresAt,
+			deleted:     true,
+		}
+		fs.userIndex[r.UserID] = append(fs.userIndex[r.UserID], r.ShortURL)
+		return
+	}
+
+	if r.IsDeleted {
+		e, exists := fs.entries[r.ShortURL]
+		if !exists || e.userID != r.UserID || e.deleted {
+			return
+		}
+
+		e.deleted = true
+		fs.dropClicks(r.ShortURL)
+		return
+	}
+
+	if _, exists := fs.entries[r.ShortURL]; exists {
+		return
+	}
+
+	fs.entries[r.ShortURL] = &entry{
+		originalURL: r.OriginalURL,
+		userID:      r.UserID,
+		expiresAt:   r.ExpiresAt,
+	}
+	fs.userIndex[r.UserID] = append(fs.userIndex[r.UserID], r.ShortURL)
+}
+
+// markExpired помечает удаленными URL, срок действия которых истек к моменту now,
+// и возвращает их количество. Вызывается под fs.mu.
+func (fs *FileStorage) markExpired(now time.Time) int64 {
+	var marked int64
+	for shortURL, e := range fs.entries {
+		if e.deleted || e.expiresAt == nil || e.expiresAt.After(now) {
+			continue
+		}
+
+		e.deleted = true
+		fs.dropClicks(shortURL)
+		marked++
 	}
 
-	return result, nil
+	return marked
 }
 
-func (fs *FileStorage) readAll() ([]record, error) {
-	if _, err := os.Stat(fs.filePath); os.IsNotExist(err) {
-		return []record{}, nil
+func (fs *FileStorage) loadData() error {
+	data, err := os.ReadFile(fs.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return fs.migrateArray(trimmed)
 	}
 
-	file, err := os.ReadFile(fs.filePath)
+	records, validSize, err := parseLines(data)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("%s: %w", fs.filePath, err)
+	}
+
+	if validSize < int64(len(data)) {
+		// Последняя запись была записана не полностью, отбрасываем ее.
+		if err := os.Truncate(fs.filePath, validSize); err != nil {
+			return err
+		}
+	} else if len(data) > 0 && data[len(data)-1] != '\n' {
+		// Последняя запись корректна, но без перевода строки: дописываем его,
+		// чтобы следующая запись не оказалась на той же строке.
+		if err := fs.appendNewline(); err != nil {
+			return err
+		}
 	}
 
-	if len(file) == 0 {
-		return []record{}, nil
+	for _, record := range records {
+		fs.apply(record)
 	}
+	fs.markExpired(time.Now())
+
+	return nil
+}
 
+// migrateArray загружает файл прежнего формата JSON-массива и переписывает его в JSON Lines.
+func (fs *FileStorage) migrateArray(data []byte) error {
 	var records []record
-	if err := json.Unmarshal(file, &records); err != nil {
-		return nil, err
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		fs.apply(record)
 	}
 
-	return records, nil
+	return fs.rewrite(records)
 }
 
-func (fs *FileStorage) appendRecord(record record) error {
-	// Используем отдельный мьютекс для файловых операций
-	var fileMu sync.Mutex
-	fileMu.Lock()
-	defer fileMu.Unlock()
+// parseLines разбирает записи JSON Lines и возвращает размер корректной части данных.
+//
+// Недописанная последняя строка без перевода строки считается оборванной записью:
+// разбор на ней останавливается, и она не входит в корректную часть. Поврежденная
+// строка, за которой следует перевод строки, - не результат оборванной записи,
+// поэтому вместо отбрасывания всех последующих записей возвращается ошибка.
+func parseLines(data []byte) ([]record, int64, error) {
+	var records []record
+	var offset int64
+
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var r record
+			if jsonErr := json.Unmarshal(line, &r); jsonErr != nil {
+				if err == io.EOF {
+					return records, offset, nil
+				}
+				return nil, 0, fmt.Errorf("%w: line %d: %v", ErrCorruptRecord, lineNum, jsonErr)
+			}
+			records = append(records, r)
+		}
+
+		offset += int64(len(line))
+
+		if err == io.EOF {
+			return records, offset, nil
+		}
+	}
+}
 
-	file, err := os.OpenFile(fs.filePath, os.O_RDWR|os.O_CREATE, 0644)
+func (fs *FileStorage) appendNewline() error {
+	file, err := os.OpenFile(fs.filePath, os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	stat, err := file.Stat()
-	if err != nil {
+	if _, err := file.Write([]byte("\n")); err != nil {
 		return err
 	}
 
-	if stat.Size() == 0 {
-		if _, err := file.Write([]byte("[\n")); err != nil {
-			return err
-		}
-	} else {
-		if _, err := file.Seek(-1, io.SeekEnd); err != nil {
+	return file.Sync()
+}
+
+// appendRecord дописывает записи в конец файла и сбрасывает их на диск.
+// При ошибке записи файл возвращается к прежнему размеру.
+func (fs *FileStorage) appendRecord(records ...record) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
 			return err
 		}
-		if _, err := file.Write([]byte(",\n")); err != nil {
+	}
+
+	file, err := os.OpenFile(fs.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		// Отрезаем частично записанные данные, иначе следующая запись
+		// продолжила бы поврежденную строку в середине файла.
+		return errors.Join(err, file.Truncate(info.Size()))
+	}
+
+	return file.Sync()
+}
+
+// rewrite атомарно заменяет содержимое файла указанными записями.
+func (fs *FileStorage) rewrite(records []record) error {
+	return writeFileAtomic(fs.filePath, records)
+}
+
+// writeFileAtomic записывает records в формате JSON Lines во временный файл рядом с path,
+// сбрасывает его на диск и атомарно подменяет им path.
+func writeFileAtomic[T any](path string, records []T) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			tmp.Close()
 			return err
 		}
 	}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(record); err != nil {
+	if err := w.Flush(); err != nil {
+		tmp.Close()
 		return err
 	}
 
-	_, err = file.Write([]byte("]"))
-	return err
-}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
 
-func (fs *FileStorage) GetStats(ctx context.Context) (*model.Stats, error) {
-	return nil, nil
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockFile ничего не делает на платформах без flock.
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile ничего не делает на платформах без flock.
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// SaveClicks сохраняет пачку переходов в одной транзакции
+func (ps *PostgresStorage) SaveClicks(ctx context.Context, clicks []model.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	const query = `INSERT INTO clicks (short_url, clicked_at, referrer, user_agent, ip, visitor_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	// Один span на всю пачку, чтобы не создавать его на каждый переход.
+	ctx, span := startQuerySpan(ctx, query)
+	span.SetAttributes(semconv.DBOperationBatchSize(len(clicks)))
+	if err := insertClicks(ctx, tx, query, clicks); err != nil {
+		tracing.End(span, err)
+		return err
+	}
+	span.End()
+
+	return tx.Commit()
+}
+
+func insertClicks(ctx context.Context, tx *sql.Tx, query string, clicks []model.Click) error {
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, click := range clicks {
+		_, err := stmt.ExecContext(ctx,
+			click.ShortURL, click.Timestamp, click.Referrer, click.UserAgent, click.IP, click.VisitorID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetClickStats возвращает статистику переходов по сокращенному URL
+func (ps *PostgresStorage) GetClickStats(ctx context.Context, shortURL string, hourlySince, dailySince time.Time) (*model.ClickStats, error) {
+	stats := &model.ClickStats{
+		Hourly: []model.ClickBucket{},
+		Daily:  []model.ClickBucket{},
+	}
+
+	err := ps.db.QueryRowContext(ctx,
+		"SELECT COUNT(*), COUNT(DISTINCT visitor_id) FROM clicks WHERE short_url = $1", shortURL,
+	).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Hourly, err = ps.clickBuckets(ctx, "hour", shortURL, hourlySince)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Daily, err = ps.clickBuckets(ctx, "day", shortURL, dailySince)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (ps *PostgresStorage) clickBuckets(ctx context.Context, unit, shortURL string, since time.Time) ([]model.ClickBucket, error) {
+	rows, err := ps.db.QueryContext(ctx,
+		`SELECT date_trunc($1, clicked_at AT TIME ZONE 'UTC') AS bucket, COUNT(*)
+		FROM clicks
+		WHERE short_url = $2 AND clicked_at >= $3
+		GROUP BY bucket
+		ORDER BY bucket`,
+		unit, shortURL, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []model.ClickBucket{}
+	for rows.Next() {
+		var bucket model.ClickBucket
+		if err := rows.Scan(&bucket.Start, &bucket.Clicks); err != nil {
+			return nil, err
+		}
+
+		bucket.Start = bucket.Start.UTC()
+		buckets = append(buckets, bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
@@ -7,66 +7,128 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/internal/tracing"
 )
 
+// shortURLIndex - имя уникального индекса по сокращенному URL.
+const shortURLIndex = "idx_short_url"
+
 // PostgressStorage реализует Storage интерфейс используя PostgreSQL
 type PostgresStorage struct {
-	db *sql.DB
+	db tracedDB
 }
 
 // NewPostgresStorage создает новый экземпляр PostgresStorage.
 func NewPostgresStorage(db *sql.DB) (*PostgresStorage, error) {
-	return &PostgresStorage{db: db}, nil
+	return &PostgresStorage{db: tracedDB{DB: db}}, nil
 }
 
-// Save сохраняет сокращенный URL и оригинальный URL в хранилище указанного пользователя
-func (ps *PostgresStorage) Save(ctx context.Context, shortURL, originalURL, userID string) error {
-	_, err := ps.db.ExecContext(ctx, "INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3)",
-		shortURL, originalURL, userID)
+// Ping проверяет соединение с базой данных
+func (ps *PostgresStorage) Ping(ctx context.Context) error {
+	return ps.db.PingContext(ctx)
+}
 
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			existingShortID, err := ps.getExistingShortID(ctx, originalURL)
-			if err != nil {
-				return err
-			}
-			return model.NewUniqueViolationError(existingShortID, err)
+// Save сохраняет сокращенный URL и оригинальный URL в хранилище указанного пользователя.
+//
+// Уникальность оригинального URL проверяется только среди действующих строк:
+// если прежний сокращенный URL удален или истек, создается новый
+func (ps *PostgresStorage) Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	err := ps.insert(ctx, shortURL, originalURL, userID, expiresAt)
+	if !errors.Is(err, errOriginalURLExists) {
+		return err
+	}
+
+	existingShortID, err := ps.getExistingShortID(ctx, originalURL)
+	if !errors.Is(err, sql.ErrNoRows) {
+		if err != nil {
+			return err
 		}
+		return model.NewUniqueViolationError(existingShortID, nil)
+	}
+
+	// Оригинальный URL занят строкой, срок действия которой истек до очередной
+	// очистки: помечаем ее удаленной, как это сделала бы очистка, и повторяем вставку.
+	_, err = ps.db.ExecContext(ctx,
+		`UPDATE urls SET is_deleted = TRUE
+		WHERE original_url = $1 AND is_deleted = FALSE AND expires_at <= now()`, originalURL)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	err = ps.insert(ctx, shortURL, originalURL, userID, expiresAt)
+	if !errors.Is(err, errOriginalURLExists) {
+		return err
+	}
+
+	existingShortID, err = ps.getExistingShortID(ctx, originalURL)
+	if err != nil {
+		return err
+	}
+	return model.NewUniqueViolationError(existingShortID, nil)
+}
+
+// errOriginalURLExists возвращается insert, если оригинальный URL уже сохранен
+var errOriginalURLExists = errors.New("original url already exists")
+
+func (ps *PostgresStorage) insert(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error {
+	_, err := ps.db.ExecContext(ctx,
+		"INSERT INTO urls (short_url, original_url, user_id, expires_at) VALUES ($1, $2, $3, $4)",
+		shortURL, originalURL, userID, expiresAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		if pgErr.ConstraintName == shortURLIndex {
+			return model.ErrShortURLExists
+		}
+		return errOriginalURLExists
+	}
+
+	return err
 }
 
 // Get возращает оригинальный URL по сокращенному
 func (ps *PostgresStorage) Get(ctx context.Context, shortURL string) (string, error) {
-	var originalURL string
-	var isDeleted bool
+	entry, err := ps.GetEntry(ctx, shortURL)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.Resolve(time.Now())
+}
+
+// GetEntry возвращает запись о сокращенном URL вместе с признаком удаления и сроком действия
+func (ps *PostgresStorage) GetEntry(ctx context.Context, shortURL string) (*model.URLEntry, error) {
+	var entry model.URLEntry
+	var expiresAt sql.NullTime
 	err := ps.db.QueryRowContext(ctx,
-		"SELECT original_URL, is_deleted FROM urls WHERE short_url = $1", shortURL,
-	).Scan(&originalURL, &isDeleted)
+		"SELECT original_URL, is_deleted, expires_at FROM urls WHERE short_url = $1", shortURL,
+	).Scan(&entry.OriginalURL, &entry.IsDeleted, &expiresAt)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrShortURLNotFound
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if isDeleted {
-		return "", nil
+	if expiresAt.Valid {
+		entry.ExpiresAt = &expiresAt.Time
 	}
 
-	return originalURL, nil
+	return &entry, nil
 }
 
-// GetByUser возвращает все пары URL когда либо сокращенных указанным пользователем
+// GetByUser возвращает все пары URL когда либо сокращенных указанным пользователем, кроме истекших
 func (ps *PostgresStorage) GetByUser(ctx context.Context, userID string) ([]model.URLPair, error) {
 	var urlPairs []model.URLPair
 	rows, err := ps.db.QueryContext(ctx,
-		"SELECT short_url, original_url FROM urls WHERE user_id = $1", userID)
+		`SELECT short_url, original_url FROM urls
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > now())`, userID)
 
 	if err != nil {
 		return nil, err
@@ -92,6 +154,53 @@ func (ps *PostgresStorage) GetByUser(ctx context.Context, userID string) ([]mode
 	return urlPairs, nil
 }
 
+// IterateByUser построчно читает пары URL пользователя, кроме истекших, и передает их в fn
+func (ps *PostgresStorage) IterateByUser(ctx context.Context, userID string, fn func(model.URLPair) error) error {
+	rows, err := ps.db.QueryContext(ctx,
+		`SELECT short_url, original_url FROM urls
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > now())
+		ORDER BY id`, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlPair model.URLPair
+		if err := rows.Scan(&urlPair.ShortURL, &urlPair.OriginalURL); err != nil {
+			return err
+		}
+
+		if err := fn(urlPair); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// IterateShortIDs построчно читает все сокращенные ID, включая удаленные и истекшие, и передает их в fn
+func (ps *PostgresStorage) IterateShortIDs(ctx context.Context, fn func(shortID string) error) error {
+	rows, err := ps.db.QueryContext(ctx, "SELECT short_url FROM urls")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortID string
+		if err := rows.Scan(&shortID); err != nil {
+			return err
+		}
+
+		if err := fn(shortID); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // DeleteByUser удаляет сокращенные URL указанного пользователя
 func (ps *PostgresStorage) DeleteByUser(ctx context.Context, userID string, shortURL []string) error {
 	if err := ps.fanInUpdate(ctx, userID, shortURL); err != nil {
@@ -101,6 +210,7 @@ func (ps *PostgresStorage) DeleteByUser(ctx context.Context, userID string, shor
 	return nil
 }
 
+// GetStats возвращает количество сокращенных URL, включая удаленные и истекшие, и количество пользователей
 func (ps *PostgresStorage) GetStats(ctx context.Context) (*model.Stats, error) {
 	stats := &model.Stats{}
 
@@ -119,6 +229,19 @@ func (ps *PostgresStorage) GetStats(ctx context.Context) (*model.Stats, error) {
 	return stats, nil
 }
 
+// DeleteExpired помечает удаленными сокращенные URL с истекшим сроком действия.
+// Строки остаются в таблице, чтобы переходы по ним получали 410 Gone, а их ID не выдавались повторно
+func (ps *PostgresStorage) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := ps.db.ExecContext(ctx,
+		`UPDATE urls SET is_deleted = TRUE
+		WHERE is_deleted = FALSE AND expires_at IS NOT NULL AND expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
 func (ps *PostgresStorage) updateDeletedForURLs(ctx context.Context, userID string, urls []string) error {
 	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -142,7 +265,9 @@ func (ps *PostgresStorage) updateDeletedForURLs(ctx context.Context, userID stri
 		strings.Join(placeholders, ", "),
 	)
 
+	ctx, span := startQuerySpan(ctx, query)
 	_, err = tx.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
 	if err != nil {
 		return err
 	}
@@ -196,7 +321,8 @@ func (ps *PostgresStorage) fanInUpdate(ctx context.Context, userID string, urls
 func (ps *PostgresStorage) getExistingShortID(ctx context.Context, originalURL string) (string, error) {
 	var shortID string
 	err := ps.db.QueryRowContext(ctx,
-		"SELECT short_url FROM urls WHERE original_url = $1",
+		`SELECT short_url FROM urls
+		WHERE original_url = $1 AND is_deleted = FALSE AND (expires_at IS NULL OR expires_at > now())`,
 		originalURL,
 	).Scan(&shortID)
 
@@ -4,20 +4,84 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/noedaka/go-url-shortener/internal/model"
 )
 
 // URLStorage определяет интерфейс для работы с хранилищем данных
 type URLStorage interface {
-	// Save сохраняет сокращенный URL и оригинальный URL в хранилище указанного пользователя
-	Save(ctx context.Context, shortURL, originalURL, userID string) error
+	// Save сохраняет сокращенный URL и оригинальный URL в хранилище указанного пользователя.
+	// Если expiresAt не nil, сокращенный URL перестает работать после указанного момента
+	Save(ctx context.Context, shortURL, originalURL, userID string, expiresAt *time.Time) error
 	// Get возращает оригинальный URL по сокращенному
 	Get(ctx context.Context, shortURL string) (string, error)
-	// GetByUser возвращает все пары URL когда либо сокращенных указанным пользователем
+	// GetByUser возвращает пары URL, сокращенные указанным пользователем, включая удаленные.
+	// Истекшие URL не возвращаются
 	GetByUser(ctx context.Context, userID string) ([]model.URLPair, error)
 	// DeleteByUser удаляет сокращенные URL указанного пользователя
 	DeleteByUser(ctx context.Context, userID string, shortURL []string) error
-	// GetStats возвращает количество сокращенных юрлов и количество пользователей
+	// GetStats возвращает количество сокращенных юрлов и количество пользователей.
+	// Учитываются все сохраненные URL, включая удаленные и истекшие
 	GetStats(ctx context.Context) (*model.Stats, error)
+	// DeleteExpired помечает удаленными сокращенные URL с истекшим сроком действия и возвращает их количество
+	DeleteExpired(ctx context.Context) (int64, error)
+	// Ping проверяет, что хранилище доступно и может принимать запись
+	Ping(ctx context.Context) error
+}
+
+// EntryGetter реализуется хранилищами, возвращающими запись о сокращенном URL целиком.
+// Используется кэшем, чтобы учитывать срок действия URL при кэшировании
+type EntryGetter interface {
+	// GetEntry возвращает запись о сокращенном URL или model.ErrShortURLNotFound
+	GetEntry(ctx context.Context, shortURL string) (*model.URLEntry, error)
+}
+
+// UserURLIterator реализуется хранилищами, умеющими отдавать URL пользователя по одному,
+// не загружая их все в память
+type UserURLIterator interface {
+	// IterateByUser вызывает fn для тех же пар URL пользователя, что возвращает GetByUser.
+	// Ошибка fn прекращает обход и возвращается вызывающему
+	IterateByUser(ctx context.Context, userID string, fn func(model.URLPair) error) error
+}
+
+// IterateByUser обходит пары URL пользователя, возвращаемые GetByUser.
+// Если хранилище не реализует UserURLIterator, пары загружаются через GetByUser
+func IterateByUser(ctx context.Context, s URLStorage, userID string, fn func(model.URLPair) error) error {
+	if iterator, ok := s.(UserURLIterator); ok {
+		return iterator.IterateByUser(ctx, userID, fn)
+	}
+
+	urlPairs, err := s.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range urlPairs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(pair); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ShortIDIterator реализуется хранилищами, умеющими перечислить все выданные сокращенные ID,
+// включая удаленные и истекшие. Используется, чтобы продолжить счетчик ID после перезапуска
+type ShortIDIterator interface {
+	// IterateShortIDs вызывает fn для каждого сокращенного ID в произвольном порядке.
+	// Ошибка fn прекращает обход и возвращается вызывающему
+	IterateShortIDs(ctx context.Context, fn func(shortID string) error) error
+}
+
+// ClickStorage определяет интерфейс для хранения переходов по сокращенным URL
+type ClickStorage interface {
+	// SaveClicks сохраняет пачку переходов
+	SaveClicks(ctx context.Context, clicks []model.Click) error
+	// GetClickStats возвращает статистику переходов по сокращенному URL.
+	// Почасовая статистика строится начиная с hourlySince, посуточная - начиная с dailySince
+	GetClickStats(ctx context.Context, shortURL string, hourlySince, dailySince time.Time) (*model.ClickStats, error)
 }
@@ -2,30 +2,67 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/noedaka/go-url-shortener/internal/logger"
+	"github.com/noedaka/go-url-shortener/internal/migrator"
+	"github.com/noedaka/go-url-shortener/internal/model"
+	"github.com/noedaka/go-url-shortener/migrations"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
 const testFilePath = "test_storage.json"
 
 func cleanup() {
 	_ = os.Remove(testFilePath)
+	_ = os.Remove(testFilePath + lockFileSuffix)
+	_ = os.Remove(testFilePath + clicksFileSuffix)
+}
+
+// openStorage открывает тестовое хранилище и закрывает его по завершении теста.
+func openStorage(tb testing.TB) *FileStorage {
+	tb.Helper()
+
+	fs, err := NewFileStorage(testFilePath)
+	if err != nil {
+		tb.Fatalf("NewFileStorage failed: %v", err)
+	}
+	tb.Cleanup(func() { _ = fs.Close() })
+
+	return fs
+}
+
+// reopenStorage закрывает хранилище и открывает его заново из того же файла.
+func reopenStorage(tb testing.TB, fs *FileStorage) *FileStorage {
+	tb.Helper()
+
+	if err := fs.Close(); err != nil {
+		tb.Fatalf("Close failed: %v", err)
+	}
+
+	return openStorage(tb)
 }
 
 func TestNewFileStorage(t *testing.T) {
 	defer cleanup()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(t)
 	assert.NotNil(t, fs, "Expected FileStorage instance, got nil")
 }
 
 func TestSaveAndGet(t *testing.T) {
 	defer cleanup()
 	ctx := context.Background()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(t)
 
-	err := fs.Save(ctx, "abc", "https://example.com", "")
+	err := fs.Save(ctx, "abc", "https://example.com", "", nil)
 	assert.NoError(t, err, "Save failed")
 
 	url, err := fs.Get(ctx, "abc")
@@ -36,7 +73,7 @@ func TestSaveAndGet(t *testing.T) {
 func TestGetNonExistent(t *testing.T) {
 	defer cleanup()
 	ctx := context.Background()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(t)
 
 	_, err := fs.Get(ctx, "nonexistent")
 	assert.Error(t, err, "Expected error for non-existent key")
@@ -45,10 +82,10 @@ func TestGetNonExistent(t *testing.T) {
 func TestMultipleSaves(t *testing.T) {
 	defer cleanup()
 	ctx := context.Background()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(t)
 
-	assert.NoError(t, fs.Save(ctx, "k1", "v1", ""), "Save k1 failed")
-	assert.NoError(t, fs.Save(ctx, "k2", "v2", ""), "Save k2 failed")
+	assert.NoError(t, fs.Save(ctx, "k1", "v1", "", nil), "Save k1 failed")
+	assert.NoError(t, fs.Save(ctx, "k2", "v2", "", nil), "Save k2 failed")
 
 	val1, err1 := fs.Get(ctx, "k1")
 	val2, err2 := fs.Get(ctx, "k2")
@@ -59,12 +96,454 @@ func TestMultipleSaves(t *testing.T) {
 	assert.Equal(t, "v2", val2, "Value for k2 mismatch")
 }
 
+func TestSaveDuplicateShortURL(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	assert.NoError(t, fs.Save(ctx, "alias", "https://example.com/1", "", nil), "Save failed")
+
+	err := fs.Save(ctx, "alias", "https://example.com/2", "", nil)
+	assert.ErrorIs(t, err, model.ErrShortURLExists, "Expected short URL conflict")
+
+	url, err := fs.Get(ctx, "alias")
+	assert.NoError(t, err, "Get failed")
+	assert.Equal(t, "https://example.com/1", url, "Original URL must not be overwritten")
+}
+
+func TestExpiredURL(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, fs.Save(ctx, "expired", "https://example.com/1", "", &past), "Save expired failed")
+	assert.NoError(t, fs.Save(ctx, "active", "https://example.com/2", "", &future), "Save active failed")
+
+	_, err := fs.Get(ctx, "expired")
+	assert.ErrorIs(t, err, model.ErrURLExpired, "Expected expired error")
+
+	url, err := fs.Get(ctx, "active")
+	assert.NoError(t, err, "Get active failed")
+	assert.Equal(t, "https://example.com/2", url, "URL mismatch")
+
+	deleted, err := fs.DeleteExpired(ctx)
+	assert.NoError(t, err, "DeleteExpired failed")
+	assert.Equal(t, int64(1), deleted, "Expected one expired URL to be purged")
+
+	url, err = fs.Get(ctx, "expired")
+	assert.NoError(t, err, "Purged URL must stay gone, not unknown")
+	assert.Empty(t, url, "Purged URL must be reported as deleted")
+	assert.ErrorIs(t, fs.Save(ctx, "expired", "https://example.com/3", "", nil), model.ErrShortURLExists,
+		"Purged ID must not be reissued")
+
+	deleted, err = fs.DeleteExpired(ctx)
+	assert.NoError(t, err, "DeleteExpired failed")
+	assert.Zero(t, deleted, "Purged URL must not be counted twice")
+
+	reloaded := reopenStorage(t, fs)
+	url, err = reloaded.Get(ctx, "expired")
+	assert.NoError(t, err, "Purged URL must stay gone after reload")
+	assert.Empty(t, url, "Purged URL must be reported as deleted after reload")
+
+	stats, err := reloaded.GetStats(ctx)
+	assert.NoError(t, err, "GetStats failed")
+	assert.Equal(t, 2, stats.URLs, "Purged URL must still be counted like in PostgresStorage")
+}
+
+func TestIterateShortIDs(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	past := time.Now().Add(-time.Minute)
+	assert.NoError(t, fs.Save(ctx, "live", "https://example.com/1", "user1", nil), "Save live failed")
+	assert.NoError(t, fs.Save(ctx, "deleted", "https://example.com/2", "user1", nil), "Save deleted failed")
+	assert.NoError(t, fs.Save(ctx, "expired", "https://example.com/3", "user1", &past), "Save expired failed")
+	assert.NoError(t, fs.DeleteByUser(ctx, "user1", []string{"deleted"}), "DeleteByUser failed")
+	_, err := fs.DeleteExpired(ctx)
+	assert.NoError(t, err, "DeleteExpired failed")
+
+	var ids []string
+	err = reopenStorage(t, fs).IterateShortIDs(ctx, func(shortID string) error {
+		ids = append(ids, shortID)
+		return nil
+	})
+	assert.NoError(t, err, "IterateShortIDs failed")
+	assert.ElementsMatch(t, []string{"live", "deleted", "expired"}, ids, "Issued IDs must include deleted and expired ones")
+}
+
+func TestClickStats(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	clicks := []model.Click{
+		{ShortURL: "abc", Timestamp: now, VisitorID: "v1"},
+		{ShortURL: "abc", Timestamp: now.Add(-time.Hour), VisitorID: "v1"},
+		{ShortURL: "abc", Timestamp: now.AddDate(0, 0, -2), VisitorID: "v2"},
+		{ShortURL: "other", Timestamp: now, VisitorID: "v3"},
+	}
+	assert.NoError(t, fs.SaveClicks(ctx, clicks), "SaveClicks failed")
+
+	reloaded := reopenStorage(t, fs)
+	stats, err := reloaded.GetClickStats(ctx, "abc", now.Add(-24*time.Hour), now.AddDate(0, 0, -30))
+	assert.NoError(t, err, "GetClickStats failed")
+
+	assert.Equal(t, 3, stats.TotalClicks, "Total clicks mismatch")
+	assert.Equal(t, 2, stats.UniqueVisitors, "Unique visitors mismatch")
+	assert.Len(t, stats.Hourly, 2, "Expected two hourly buckets")
+	assert.Len(t, stats.Daily, 2, "Expected two daily buckets")
+	assert.Equal(t, 2, stats.Daily[1].Clicks, "Expected two clicks today")
+}
+
+func TestClickStats_DeletedURL(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	now := time.Now()
+	assert.NoError(t, fs.Save(ctx, "keep", "https://example.com/1", "user1", nil), "Save keep failed")
+	assert.NoError(t, fs.Save(ctx, "gone", "https://example.com/2", "user1", nil), "Save gone failed")
+	assert.NoError(t, fs.SaveClicks(ctx, []model.Click{
+		{ShortURL: "keep", Timestamp: now, VisitorID: "v1"},
+		{ShortURL: "gone", Timestamp: now, VisitorID: "v1"},
+	}), "SaveClicks failed")
+
+	assert.NoError(t, fs.DeleteByUser(ctx, "user1", []string{"gone"}), "DeleteByUser failed")
+	assert.NoError(t, fs.SaveClicks(ctx, []model.Click{{ShortURL: "gone", Timestamp: now, VisitorID: "v2"}}),
+		"SaveClicks failed")
+
+	for _, store := range []*FileStorage{fs, reopenStorage(t, fs)} {
+		stats, err := store.GetClickStats(ctx, "gone", now.Add(-time.Hour), now.AddDate(0, 0, -1))
+		assert.NoError(t, err, "GetClickStats failed")
+		assert.Zero(t, stats.TotalClicks, "Clicks of a deleted URL must be dropped")
+	}
+}
+
+func TestCompactClicks(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	assert.NoError(t, fs.Save(ctx, "abc", "https://example.com/1", "user1", nil), "Save failed")
+	assert.NoError(t, fs.SaveClicks(ctx, []model.Click{
+		{ShortURL: "abc", Timestamp: now, VisitorID: "v1"},
+		{ShortURL: "abc", Timestamp: now.Add(-time.Hour), VisitorID: "v1"},
+		{ShortURL: "abc", Timestamp: now.AddDate(0, 0, -2), VisitorID: "v2"},
+	}), "SaveClicks failed")
+
+	assert.NoError(t, fs.Compact(ctx), "Compact failed")
+
+	data, err := os.ReadFile(testFilePath + clicksFileSuffix)
+	assert.NoError(t, err, "ReadFile failed")
+	assert.Equal(t, 1, strings.Count(string(data), "\n"), "Expected one snapshot per URL after compaction")
+
+	reloaded := reopenStorage(t, fs)
+	assert.NoError(t, reloaded.SaveClicks(ctx, []model.Click{{ShortURL: "abc", Timestamp: now, VisitorID: "v3"}}),
+		"SaveClicks after compaction failed")
+
+	reloaded = reopenStorage(t, reloaded)
+	stats, err := reloaded.GetClickStats(ctx, "abc", now.Add(-24*time.Hour), now.AddDate(0, 0, -30))
+	assert.NoError(t, err, "GetClickStats failed")
+	assert.Equal(t, 4, stats.TotalClicks, "Total clicks mismatch")
+	assert.Equal(t, 3, stats.UniqueVisitors, "Unique visitors mismatch")
+	assert.Equal(t, []model.ClickBucket{
+		{Start: now.Add(-time.Hour).Truncate(time.Hour), Clicks: 1},
+		{Start: now.Truncate(time.Hour), Clicks: 2},
+	}, stats.Hourly, "Hourly buckets mismatch")
+	assert.Len(t, stats.Daily, 2, "Expected two daily buckets")
+}
+
+func TestClickStats_PrunesOldHourlyBuckets(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	var clicks []model.Click
+	for hour := 72; hour >= 0; hour-- {
+		clicks = append(clicks, model.Click{ShortURL: "abc", Timestamp: now.Add(-time.Duration(hour) * time.Hour), VisitorID: "v1"})
+	}
+	// Запоздавший переход старше окна не должен вернуть удаленный интервал.
+	clicks = append(clicks, model.Click{ShortURL: "abc", Timestamp: now.Add(-48 * time.Hour), VisitorID: "v1"})
+	assert.NoError(t, fs.SaveClicks(ctx, clicks), "SaveClicks failed")
+
+	wantHourly := int(HourlyClickWindow/time.Hour) + 1
+	assert.Len(t, fs.clicks["abc"].hourly, wantHourly, "Hourly buckets outside the window must be dropped")
+
+	assert.NoError(t, fs.Compact(ctx), "Compact failed")
+	data, err := os.ReadFile(testFilePath + clicksFileSuffix)
+	assert.NoError(t, err, "ReadFile failed")
+	assert.NotContains(t, string(data), now.Add(-48*time.Hour).Truncate(time.Hour).Format(time.RFC3339), "Snapshot must not contain dropped buckets")
+
+	reloaded := reopenStorage(t, fs)
+	assert.Len(t, reloaded.clicks["abc"].hourly, wantHourly, "Hourly buckets mismatch after reload")
+
+	stats, err := reloaded.GetClickStats(ctx, "abc", now.Add(-HourlyClickWindow).Truncate(time.Hour), now.AddDate(0, 0, -30))
+	assert.NoError(t, err, "GetClickStats failed")
+	assert.Equal(t, 74, stats.TotalClicks, "Pruning must not change the total")
+	assert.Len(t, stats.Hourly, wantHourly, "Hourly stats mismatch")
+	assert.Len(t, stats.Daily, 4, "Daily buckets must be kept")
+}
+
+func TestDeleteByUser(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	assert.NoError(t, fs.Save(ctx, "own", "https://example.com/1", "user1", nil), "Save own failed")
+	assert.NoError(t, fs.Save(ctx, "keep", "https://example.com/2", "user1", nil), "Save keep failed")
+	assert.NoError(t, fs.Save(ctx, "foreign", "https://example.com/3", "user2", nil), "Save foreign failed")
+
+	err := fs.DeleteByUser(ctx, "user1", []string{"own", "foreign", "missing"})
+	assert.NoError(t, err, "DeleteByUser failed")
+
+	for _, storage := range []*FileStorage{fs, reopenStorage(t, fs)} {
+		url, err := storage.Get(ctx, "own")
+		assert.NoError(t, err, "Get own failed")
+		assert.Equal(t, "", url, "Deleted URL must be reported as empty string")
+
+		url, err = storage.Get(ctx, "foreign")
+		assert.NoError(t, err, "Get foreign failed")
+		assert.Equal(t, "https://example.com/3", url, "URL of another user must not be deleted")
+
+		pairs, err := storage.GetByUser(ctx, "user1")
+		assert.NoError(t, err, "GetByUser failed")
+		assert.Equal(t, []model.URLPair{
+			{ShortURL: "own", OriginalURL: "https://example.com/1"},
+			{ShortURL: "keep", OriginalURL: "https://example.com/2"},
+		}, pairs, "Deleted URLs must be listed like in PostgresStorage")
+	}
+
+	err = fs.Save(ctx, "own", "https://example.com/4", "user1", nil)
+	assert.ErrorIs(t, err, model.ErrShortURLExists, "Deleted short URL must not be reused")
+}
+
+func TestGetStats(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	assert.NoError(t, fs.Save(ctx, "a", "https://example.com/1", "user1", nil), "Save a failed")
+	assert.NoError(t, fs.Save(ctx, "b", "https://example.com/2", "user1", nil), "Save b failed")
+	assert.NoError(t, fs.Save(ctx, "c", "https://example.com/3", "user2", nil), "Save c failed")
+
+	stats, err := fs.GetStats(ctx)
+	assert.NoError(t, err, "GetStats failed")
+	assert.Equal(t, &model.Stats{URLs: 3, Users: 2}, stats)
+
+	assert.NoError(t, fs.DeleteByUser(ctx, "user2", []string{"c"}), "DeleteByUser failed")
+
+	for _, storage := range []*FileStorage{fs, reopenStorage(t, fs)} {
+		stats, err := storage.GetStats(ctx)
+		assert.NoError(t, err, "GetStats failed")
+		assert.Equal(t, &model.Stats{URLs: 3, Users: 2}, stats, "Deleted URLs must be counted like in PostgresStorage")
+	}
+}
+
+// TestBackendsAgree выполняет одинаковые операции с FileStorage и PostgresStorage
+// и сравнивает статистику и списки URL пользователей. Для запуска нужна база
+// PostgreSQL в STORAGE_TEST_DATABASE_DSN, таблицы urls и clicks в ней очищаются.
+func TestBackendsAgree(t *testing.T) {
+	dsn := os.Getenv("STORAGE_TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("STORAGE_TEST_DATABASE_DSN is not set")
+	}
+
+	defer cleanup()
+	ctx := context.Background()
+	logger.Log = zap.NewNop()
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	defer db.Close()
+
+	m, err := migrator.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrator.New failed: %v", err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, "TRUNCATE urls, clicks"); err != nil {
+		t.Fatalf("TRUNCATE failed: %v", err)
+	}
+
+	ps, err := NewPostgresStorage(db)
+	if err != nil {
+		t.Fatalf("NewPostgresStorage failed: %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	for _, storage := range []URLStorage{openStorage(t), ps} {
+		assert.NoError(t, storage.Save(ctx, "keep", "https://example.com/1", "user1", nil), "Save keep failed")
+		assert.NoError(t, storage.Save(ctx, "gone", "https://example.com/2", "user1", nil), "Save gone failed")
+		assert.NoError(t, storage.Save(ctx, "other", "https://example.com/3", "user2", nil), "Save other failed")
+		assert.NoError(t, storage.Save(ctx, "expired", "https://example.com/4", "user3", &past), "Save expired failed")
+		assert.NoError(t, storage.DeleteByUser(ctx, "user1", []string{"gone"}), "DeleteByUser failed")
+		assert.NoError(t, storage.DeleteByUser(ctx, "user2", []string{"other"}), "DeleteByUser failed")
+
+		stats, err := storage.GetStats(ctx)
+		assert.NoError(t, err, "GetStats failed")
+		assert.Equal(t, &model.Stats{URLs: 4, Users: 3}, stats, "%T stats mismatch", storage)
+
+		pairs, err := storage.GetByUser(ctx, "user1")
+		assert.NoError(t, err, "GetByUser failed")
+		assert.ElementsMatch(t, []model.URLPair{
+			{ShortURL: "keep", OriginalURL: "https://example.com/1"},
+			{ShortURL: "gone", OriginalURL: "https://example.com/2"},
+		}, pairs, "%T user URLs mismatch", storage)
+
+		pairs, err = storage.GetByUser(ctx, "user3")
+		assert.NoError(t, err, "GetByUser failed")
+		assert.Empty(t, pairs, "%T must not list expired URLs", storage)
+	}
+}
+
+func TestMigrateArrayFormat(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+
+	legacy := `[
+  {"uuid": "1", "short_url": "abc", "original_url": "https://example.com/1", "user_id": "user1"},
+  {"uuid": "2", "short_url": "def", "original_url": "https://example.com/2", "user_id": "user1"}
+]`
+	assert.NoError(t, os.WriteFile(testFilePath, []byte(legacy), 0644), "WriteFile failed")
+
+	fs := openStorage(t)
+	assert.NoError(t, fs.Save(ctx, "ghi", "https://example.com/3", "user1", nil), "Save after migration failed")
+
+	data, err := os.ReadFile(testFilePath)
+	assert.NoError(t, err, "ReadFile failed")
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3, "Expected one JSON record per line")
+
+	pairs, err := reopenStorage(t, fs).GetByUser(ctx, "user1")
+	assert.NoError(t, err, "GetByUser failed")
+	assert.Len(t, pairs, 3, "Expected all records after migration")
+}
+
+func TestTornWriteRecovery(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+	assert.NoError(t, fs.Save(ctx, "abc", "https://example.com/1", "", nil), "Save failed")
+
+	file, err := os.OpenFile(testFilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err, "OpenFile failed")
+	_, err = file.WriteString(`{"uuid": "2", "short_url": "de`)
+	assert.NoError(t, err, "WriteString failed")
+	assert.NoError(t, file.Close(), "Close failed")
+
+	recovered := reopenStorage(t, fs)
+	assert.NoError(t, recovered.Save(ctx, "def", "https://example.com/2", "", nil), "Save after recovery failed")
+
+	reloaded := reopenStorage(t, recovered)
+	for short, want := range map[string]string{"abc": "https://example.com/1", "def": "https://example.com/2"} {
+		url, err := reloaded.Get(ctx, short)
+		assert.NoError(t, err, "Get failed")
+		assert.Equal(t, want, url, "URL mismatch")
+	}
+}
+
+func TestCorruptRecordInTheMiddle(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+	assert.NoError(t, fs.Save(ctx, "abc", "https://example.com/1", "", nil), "Save failed")
+
+	file, err := os.OpenFile(testFilePath, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err, "OpenFile failed")
+	_, err = file.WriteString("{\"uuid\": \"2\", \"short_url\": \"de\n")
+	assert.NoError(t, err, "WriteString failed")
+	assert.NoError(t, file.Close(), "Close failed")
+	assert.NoError(t, fs.Save(ctx, "ghi", "https://example.com/3", "", nil), "Save after corrupt record failed")
+	assert.NoError(t, fs.Close(), "Close failed")
+
+	before, err := os.ReadFile(testFilePath)
+	assert.NoError(t, err, "ReadFile failed")
+
+	_, err = NewFileStorage(testFilePath)
+	assert.ErrorIs(t, err, ErrCorruptRecord, "Corrupt record in the middle must be reported")
+	assert.ErrorContains(t, err, "line 2")
+
+	after, err := os.ReadFile(testFilePath)
+	assert.NoError(t, err, "ReadFile failed")
+	assert.Equal(t, string(before), string(after), "Records after the corrupt line must not be truncated")
+}
+
+func TestCompact(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	past := time.Now().Add(-time.Minute)
+	assert.NoError(t, fs.Save(ctx, "keep", "https://example.com/1", "user1", nil), "Save keep failed")
+	assert.NoError(t, fs.Save(ctx, "gone", "https://example.com/2", "user1", nil), "Save gone failed")
+	assert.NoError(t, fs.Save(ctx, "expired", "https://example.com/3", "user1", &past), "Save expired failed")
+	assert.NoError(t, fs.DeleteByUser(ctx, "user1", []string{"gone"}), "DeleteByUser failed")
+
+	assert.NoError(t, fs.Compact(ctx), "Compact failed")
+
+	data, err := os.ReadFile(testFilePath)
+	assert.NoError(t, err, "ReadFile failed")
+	assert.Equal(t, 3, strings.Count(string(data), "\n"), "Expected one record per short URL after compaction")
+
+	reloaded := reopenStorage(t, fs)
+	url, err := reloaded.Get(ctx, "keep")
+	assert.NoError(t, err, "Get keep failed")
+	assert.Equal(t, "https://example.com/1", url, "URL mismatch")
+
+	for _, short := range []string{"gone", "expired"} {
+		url, err = reloaded.Get(ctx, short)
+		assert.NoError(t, err, "Get %s failed", short)
+		assert.Empty(t, url, "Removed URL %s must stay deleted after compaction", short)
+		assert.ErrorIs(t, reloaded.Save(ctx, short, "https://example.com/4", "user2", nil), model.ErrShortURLExists,
+			"Removed ID %s must not be reissued after compaction", short)
+	}
+
+	assert.NoError(t, reloaded.DeleteByUser(ctx, "user1", []string{"keep"}), "DeleteByUser failed")
+	assert.NoError(t, reloaded.Compact(ctx), "Second compact failed")
+	reloaded = reopenStorage(t, reloaded)
+	url, err = reloaded.Get(ctx, "keep")
+	assert.NoError(t, err, "Get keep failed")
+	assert.Empty(t, url, "URL deleted after reload must stay deleted after compaction")
+
+	stats, err := reloaded.GetStats(ctx)
+	assert.NoError(t, err, "GetStats failed")
+	assert.Equal(t, &model.Stats{URLs: 3, Users: 1}, stats)
+}
+
+func TestCompactKeepsStats(t *testing.T) {
+	defer cleanup()
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	past := time.Now().Add(-time.Minute)
+	assert.NoError(t, fs.Save(ctx, "keep", "https://example.com/1", "user1", nil), "Save keep failed")
+	assert.NoError(t, fs.Save(ctx, "gone", "https://example.com/2", "user1", nil), "Save gone failed")
+	assert.NoError(t, fs.Save(ctx, "expired", "https://example.com/3", "user2", &past), "Save expired failed")
+	assert.NoError(t, fs.DeleteByUser(ctx, "user1", []string{"gone"}), "DeleteByUser failed")
+	assert.NoError(t, fs.Compact(ctx), "Compact failed")
+
+	reloaded := reopenStorage(t, fs)
+
+	stats, err := reloaded.GetStats(ctx)
+	assert.NoError(t, err, "GetStats failed")
+	assert.Equal(t, &model.Stats{URLs: 3, Users: 2}, stats)
+}
+
 func TestSaveEmptyValues(t *testing.T) {
 	defer cleanup()
 	ctx := context.Background()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(t)
 
-	err := fs.Save(ctx, "", "", "")
+	err := fs.Save(ctx, "", "", "", nil)
 	assert.NoError(t, err, "Save with empty values failed")
 
 	val, err := fs.Get(ctx, "")
@@ -72,6 +551,88 @@ func TestSaveEmptyValues(t *testing.T) {
 	assert.Equal(t, "", val, "Expected empty string for empty key")
 }
 
+func TestFileStorageLocked(t *testing.T) {
+	cleanup()
+	defer cleanup()
+
+	fs := openStorage(t)
+
+	_, err := NewFileStorage(testFilePath)
+	assert.ErrorIs(t, err, ErrStorageLocked)
+
+	assert.NoError(t, fs.Close())
+
+	second, err := NewFileStorage(testFilePath)
+	assert.NoError(t, err)
+	assert.NoError(t, second.Close())
+}
+
+func TestFileStoragePing(t *testing.T) {
+	cleanup()
+	defer cleanup()
+
+	fs := openStorage(t)
+	assert.NoError(t, fs.Ping(context.Background()))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, fs.Ping(canceled), context.Canceled)
+
+	assert.NoError(t, fs.Close())
+	assert.ErrorIs(t, fs.Ping(context.Background()), ErrStorageClosed)
+}
+
+func TestConcurrentSave(t *testing.T) {
+	cleanup()
+	defer cleanup()
+
+	ctx := context.Background()
+	fs := openStorage(t)
+
+	const (
+		workers = 16
+		perUser = 50
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			userID := fmt.Sprintf("user%d", w)
+			for i := 0; i < perUser; i++ {
+				short := fmt.Sprintf("s%d_%d", w, i)
+				assert.NoError(t, fs.Save(ctx, short, "https://example.com/"+short, userID, nil))
+
+				_, err := fs.Get(ctx, short)
+				assert.NoError(t, err)
+
+				if i%10 == 0 {
+					_, err := fs.GetStats(ctx)
+					assert.NoError(t, err)
+				}
+			}
+
+			// Параллельно с записью другие горутины удаляют часть своих URL.
+			assert.NoError(t, fs.DeleteByUser(ctx, userID, []string{fmt.Sprintf("s%d_0", w)}))
+		}(w)
+	}
+	wg.Wait()
+
+	wantURLs := workers * perUser
+	for _, storage := range []*FileStorage{fs, reopenStorage(t, fs)} {
+		stats, err := storage.GetStats(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, wantURLs, stats.URLs)
+		assert.Equal(t, workers, stats.Users)
+
+		pairs, err := storage.GetByUser(ctx, "user0")
+		assert.NoError(t, err)
+		assert.Len(t, pairs, perUser)
+	}
+}
+
 func BenchmarkSave(b *testing.B) {
 	ctx := context.Background()
 
@@ -79,21 +640,27 @@ func BenchmarkSave(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
 		cleanup()
-		fs := NewFileStorage(testFilePath)
+		fs := openStorage(b)
 		b.StartTimer()
 
-		err := fs.Save(ctx, "test-key", "https://example.com", "")
+		err := fs.Save(ctx, "test-key", "https://example.com", "", nil)
 		if err != nil {
 			b.Fatalf("Save failed: %v", err)
 		}
+
+		b.StopTimer()
+		_ = fs.Close()
+		b.StartTimer()
 	}
 }
 
 func BenchmarkGet(b *testing.B) {
+	cleanup()
+	defer cleanup()
 	ctx := context.Background()
-	fs := NewFileStorage(testFilePath)
+	fs := openStorage(b)
 
-	err := fs.Save(ctx, "test-key", "https://example.com", "")
+	err := fs.Save(ctx, "test-key", "https://example.com", "", nil)
 	if err != nil {
 		b.Fatalf("Setup failed: %v", err)
 	}
@@ -114,12 +681,12 @@ func BenchmarkSaveAndGet(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
 		cleanup()
-		fs := NewFileStorage(testFilePath)
+		fs := openStorage(b)
 		b.StartTimer()
 
 		key := "test-key"
 		value := "https://example.com"
-		err := fs.Save(ctx, key, value, "")
+		err := fs.Save(ctx, key, value, "", nil)
 		if err != nil {
 			b.Fatalf("Save failed: %v", err)
 		}
@@ -128,6 +695,10 @@ func BenchmarkSaveAndGet(b *testing.B) {
 		if err != nil {
 			b.Fatalf("Get failed: %v", err)
 		}
+
+		b.StopTimer()
+		_ = fs.Close()
+		b.StartTimer()
 	}
 }
 
@@ -138,16 +709,20 @@ func BenchmarkMultipleSaves(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
 		cleanup()
-		fs := NewFileStorage(testFilePath)
+		fs := openStorage(b)
 		b.StartTimer()
 
 		for j := 0; j < 10; j++ {
 			key := string(rune('a' + j))
 			value := "https://example.com/" + key
-			err := fs.Save(ctx, key, value, "")
+			err := fs.Save(ctx, key, value, "", nil)
 			if err != nil {
 				b.Fatalf("Save failed for key %s: %v", key, err)
 			}
 		}
+
+		b.StopTimer()
+		_ = fs.Close()
+		b.StartTimer()
 	}
 }
@@ -0,0 +1,133 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ErrNoACMEDomains возвращается, если для ACME не задан ни один домен.
+var ErrNoACMEDomains = errors.New("no ACME domains configured")
+
+// ACMEConfig - параметры автоматического получения сертификатов по протоколу ACME.
+type ACMEConfig struct {
+	// Domains - домены, для которых разрешено выпускать сертификаты.
+	Domains []string
+	// CacheDir - каталог, в котором хранятся ключ аккаунта и выпущенные сертификаты.
+	CacheDir string
+	// DirectoryURL - адрес каталога ACME-сервера. По умолчанию используется Let's Encrypt.
+	DirectoryURL string
+	// Email - контактный адрес аккаунта ACME.
+	Email string
+	// CAFile - CA, которому доверять при обращении к ACME-серверу,
+	// например сертификат локального тестового сервера Pebble.
+	CAFile string
+}
+
+// NewACMEManager создает менеджер, получающий и продлевающий сертификаты для cfg.Domains.
+//
+// Запросы проверки HTTP-01 обслуживает ChallengeHandler,
+// проверка TLS-ALPN-01 выполняется через Manager.TLSConfig.
+func NewACMEManager(cfg ACMEConfig) (*autocert.Manager, error) {
+	if len(cfg.Domains) == 0 {
+		return nil, ErrNoACMEDomains
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Email:      cfg.Email,
+	}
+
+	if cfg.DirectoryURL != "" || cfg.CAFile != "" {
+		client := &acme.Client{DirectoryURL: cfg.DirectoryURL}
+
+		if cfg.CAFile != "" {
+			data, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read ACME CA file: %w", err)
+			}
+
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in ACME CA file %s", cfg.CAFile)
+			}
+
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+			client.HTTPClient = &http.Client{Transport: transport}
+		}
+
+		manager.Client = client
+	}
+
+	return manager, nil
+}
+
+// ChallengeHandler отвечает на проверки HTTP-01, остальные запросы передает fallback.
+//
+// Порт удаляется из заголовка Host, чтобы проверка проходила и на нестандартном
+// HTTP-порту, например при тестировании с Pebble.
+func ChallengeHandler(manager *autocert.Manager, fallback http.Handler) http.Handler {
+	challenges := manager.HTTPHandler(fallback)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, _, err := net.SplitHostPort(r.Host); err == nil {
+			r = r.WithContext(r.Context())
+			r.Host = host
+		}
+
+		challenges.ServeHTTP(w, r)
+	})
+}
+
+// ParseDomains разбирает список доменов, разделенных запятыми.
+func ParseDomains(s string) []string {
+	var domains []string
+	for _, domain := range strings.Split(s, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
+
+// RedirectHandler перенаправляет запросы на тот же путь по HTTPS.
+//
+// httpsAddr - адрес HTTPS-сервера; порт добавляется в адрес перенаправления,
+// если он отличается от стандартного 443.
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		target := url.URL{
+			Scheme:   "https",
+			Host:     host,
+			Path:     r.URL.Path,
+			RawPath:  r.URL.RawPath,
+			RawQuery: r.URL.RawQuery,
+		}
+
+		// 308 сохраняет метод и тело запроса.
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,116 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		target    string
+		want      string
+	}{
+		{"Default port", ":443", "http://example.com/abc?x=1", "https://example.com/abc?x=1"},
+		{"Custom port", "127.0.0.1:8443", "http://example.com:8080/abc", "https://example.com:8443/abc"},
+		{"Escaped path", ":443", "http://example.com/a%2Fb", "https://example.com/a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			RedirectHandler(tt.httpsAddr).ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestChallengeHandler_Fallback(t *testing.T) {
+	manager, err := NewACMEManager(ACMEConfig{Domains: []string{"example.com"}, CacheDir: t.TempDir()})
+	assert.NoError(t, err)
+
+	handler := ChallengeHandler(manager, RedirectHandler(":443"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com:8080/abc", nil))
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://example.com/abc", w.Header().Get("Location"))
+
+	// Токен проверки неизвестен, но хост разрешен несмотря на нестандартный порт.
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com:8080/.well-known/acme-challenge/token", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://other.com/.well-known/acme-challenge/token", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestParseDomains(t *testing.T) {
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, ParseDomains(" a.example.com, ,b.example.com "))
+	assert.Empty(t, ParseDomains(""))
+}
+
+func TestNewACMEManager_Errors(t *testing.T) {
+	_, err := NewACMEManager(ACMEConfig{CacheDir: t.TempDir()})
+	assert.ErrorIs(t, err, ErrNoACMEDomains)
+
+	_, err = NewACMEManager(ACMEConfig{Domains: []string{"example.com"}, CAFile: "missing.pem"})
+	assert.Error(t, err)
+}
+
+// TestACMEManager_Pebble получает сертификат у локального ACME-сервера Pebble.
+//
+// Запускается, только если задана переменная ACME_TEST_DIRECTORY_URL, например:
+//
+//	ACME_TEST_DIRECTORY_URL=https://localhost:14000/dir \
+//	ACME_TEST_CA_FILE=pebble/test/certs/pebble.minica.pem \
+//	ACME_TEST_DOMAIN=shortener.test \
+//	go test ./internal/tlsutil -run Pebble
+//
+// Домен должен указывать на локальный хост, а ACME_TEST_HTTP_ADDR (по умолчанию :5002)
+// совпадать с httpPort из конфигурации Pebble. Клиент golang.org/x/crypto/acme ожидает
+// заголовок Location в ответе на finalize, который Pebble возвращает до v2.5 (проверено на v2.4.0).
+func TestACMEManager_Pebble(t *testing.T) {
+	directoryURL := os.Getenv("ACME_TEST_DIRECTORY_URL")
+	if directoryURL == "" {
+		t.Skip("ACME_TEST_DIRECTORY_URL is not set")
+	}
+
+	domain := os.Getenv("ACME_TEST_DOMAIN")
+	httpAddr := os.Getenv("ACME_TEST_HTTP_ADDR")
+	if httpAddr == "" {
+		httpAddr = ":5002"
+	}
+
+	manager, err := NewACMEManager(ACMEConfig{
+		Domains:      []string{domain},
+		CacheDir:     t.TempDir(),
+		DirectoryURL: directoryURL,
+		CAFile:       os.Getenv("ACME_TEST_CA_FILE"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challengeServer := &http.Server{Addr: httpAddr, Handler: ChallengeHandler(manager, RedirectHandler(":443"))}
+	go func() { _ = challengeServer.ListenAndServe() }()
+	t.Cleanup(func() { _ = challengeServer.Close() })
+	time.Sleep(100 * time.Millisecond)
+
+	cert, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: domain})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{domain}, cert.Leaf.DNSNames)
+}
@@ -0,0 +1,119 @@
+// Модуль tlsutil загружает TLS-сертификаты сервера и позволяет перечитывать их без перезапуска.
+//
+// Одна и та же конфигурация используется HTTP- и gRPC-серверами.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// ErrNoClientCA возвращается, если файл CA клиентов не содержит ни одного сертификата.
+var ErrNoClientCA = errors.New("no certificates found in client CA file")
+
+// keyPair - сертификат сервера и пул CA клиентов, загруженные одновременно.
+type keyPair struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// Reloader хранит сертификат сервера и пул CA для проверки клиентских сертификатов.
+//
+// Reload перечитывает файлы; новые соединения используют последние успешно
+// загруженные сертификаты, установленные соединения не затрагиваются.
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	current atomic.Pointer[keyPair]
+}
+
+// NewReloader загружает сертификат и ключ сервера.
+//
+// Если clientCAFile не пуст, сервер запрашивает клиентские сертификаты
+// и проверяет их по CA из этого файла (mTLS).
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload перечитывает сертификаты с диска.
+//
+// При ошибке продолжают использоваться ранее загруженные сертификаты.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS key pair: %w", err)
+	}
+
+	pair := &keyPair{cert: &cert}
+
+	if r.clientCAFile != "" {
+		data, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA file: %w", err)
+		}
+
+		pair.clientCAs = x509.NewCertPool()
+		if !pair.clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%w: %s", ErrNoClientCA, r.clientCAFile)
+		}
+	}
+
+	r.current.Store(pair)
+
+	return nil
+}
+
+// MutualTLS сообщает, проверяются ли клиентские сертификаты.
+func (r *Reloader) MutualTLS() bool {
+	return r.clientCAFile != ""
+}
+
+// ServerConfig возвращает конфигурацию TLS сервера, использующую актуальные сертификаты.
+//
+// nextProtos задает протоколы ALPN. Клиентский сертификат необязателен:
+// проверять его наличие должны обработчики, которым он нужен (см. HasVerifiedClientCert).
+func (r *Reloader) ServerConfig(nextProtos ...string) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.current.Load().cert, nil
+		},
+	}
+
+	if r.MutualTLS() {
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			pair := r.current.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*pair.cert},
+				ClientCAs:    pair.clientCAs,
+				ClientAuth:   tls.VerifyClientCertIfGiven,
+			}, nil
+		}
+	}
+
+	return cfg
+}
+
+// HasVerifiedClientCert сообщает, предъявил ли клиент сертификат, подписанный доверенным CA.
+func HasVerifiedClientCert(state *tls.ConnectionState) bool {
+	return state != nil && len(state.VerifiedChains) > 0
+}
@@ -0,0 +1,247 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCert выпускает сертификат, подписанный parent. Если parent равен nil, выпускается самоподписанный CA.
+func newTestCert(t *testing.T, name string, parent *testCert, usage ...x509.ExtKeyUsage) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  usage,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) *tls.Certificate {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := tls.X509KeyPair(c.pem, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &cert
+}
+
+func writeKeyPair(t *testing.T, dir string, c *testCert) (string, string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, c.pem, 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+// handshake устанавливает TLS-соединение с сервером и возвращает состояния соединения
+// на стороне сервера и клиента.
+func handshake(t *testing.T, serverCfg *tls.Config, clientCfg *tls.Config) (tls.ConnectionState, tls.ConnectionState, error) {
+	t.Helper()
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	serverResult := make(chan result, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			serverResult <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		tlsConn := conn.(*tls.Conn)
+		err = tlsConn.Handshake()
+		serverResult <- result{state: tlsConn.ConnectionState(), err: err}
+	}()
+
+	client, err := tls.Dial("tcp", listener.Addr().String(), clientCfg)
+	if err != nil {
+		<-serverResult
+		return tls.ConnectionState{}, tls.ConnectionState{}, err
+	}
+	defer client.Close()
+
+	server := <-serverResult
+	return server.state, client.ConnectionState(), server.err
+}
+
+func TestReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil)
+	first := newTestCert(t, "first", ca, x509.ExtKeyUsageServerAuth)
+
+	certFile, keyFile := writeKeyPair(t, dir, first)
+	certs, err := NewReloader(certFile, keyFile, "")
+	assert.NoError(t, err)
+	assert.False(t, certs.MutualTLS())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+
+	_, state, err := handshake(t, certs.ServerConfig(), clientCfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", state.PeerCertificates[0].Subject.CommonName)
+
+	second := newTestCert(t, "second", ca, x509.ExtKeyUsageServerAuth)
+	writeKeyPair(t, dir, second)
+	assert.NoError(t, certs.Reload())
+
+	_, state, err = handshake(t, certs.ServerConfig(), clientCfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", state.PeerCertificates[0].Subject.CommonName)
+
+	// Поврежденный файл не заменяет загруженный ранее сертификат.
+	assert.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0600))
+	assert.Error(t, certs.Reload())
+
+	_, state, err = handshake(t, certs.ServerConfig(), clientCfg)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", state.PeerCertificates[0].Subject.CommonName)
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil)
+	server := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, "client", ca, x509.ExtKeyUsageClientAuth)
+	otherCA := newTestCert(t, "other-ca", nil)
+	stranger := newTestCert(t, "stranger", otherCA, x509.ExtKeyUsageClientAuth)
+
+	certFile, keyFile := writeKeyPair(t, dir, server)
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, ca.pem, 0600))
+
+	certs, err := NewReloader(certFile, keyFile, caFile)
+	assert.NoError(t, err)
+	assert.True(t, certs.MutualTLS())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tests := []struct {
+		name         string
+		clientCert   *tls.Certificate
+		wantErr      bool
+		wantVerified bool
+	}{
+		{"Without client certificate", &tls.Certificate{}, false, false},
+		{"Trusted client certificate", client.tlsCertificate(t), false, true},
+		{"Untrusted client certificate", stranger.tlsCertificate(t), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg := &tls.Config{
+				RootCAs:    roots,
+				ServerName: "127.0.0.1",
+				// Сертификат отправляется, даже если он не подписан CA из запроса сервера.
+				GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return tt.clientCert, nil
+				},
+			}
+
+			state, _, err := handshake(t, certs.ServerConfig(), clientCfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVerified, HasVerifiedClientCert(&state))
+		})
+	}
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test-ca", nil)
+	certFile, keyFile := writeKeyPair(t, dir, newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth))
+
+	emptyCA := filepath.Join(dir, "empty.pem")
+	assert.NoError(t, os.WriteFile(emptyCA, []byte("no certificates here"), 0600))
+
+	_, err := NewReloader(filepath.Join(dir, "missing.pem"), keyFile, "")
+	assert.Error(t, err)
+
+	_, err = NewReloader(certFile, keyFile, filepath.Join(dir, "missing-ca.pem"))
+	assert.Error(t, err)
+
+	_, err = NewReloader(certFile, keyFile, emptyCA)
+	assert.ErrorIs(t, err, ErrNoClientCA)
+}
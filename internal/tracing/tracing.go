@@ -0,0 +1,109 @@
+// Модуль tracing настраивает трассировку OpenTelemetry.
+//
+// Контекст трассировки передается между сервисами в заголовках W3C Trace Context
+// (traceparent, tracestate) и baggage.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры трасс.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config - настройки трассировки.
+type Config struct {
+	// Exporter - куда отправлять трассы: ExporterNone, ExporterOTLP или ExporterStdout.
+	Exporter string
+	// Endpoint - адрес коллектора OTLP/gRPC вида host:port. Если не задан,
+	// используется OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4317.
+	Endpoint string
+	// Insecure отключает TLS при подключении к коллектору.
+	Insecure bool
+	// SampleRatio - доля записываемых трасс, начатых сервисом.
+	// Решение вызывающего сервиса о записи трассы соблюдается всегда.
+	SampleRatio float64
+	// ServiceName - имя сервиса в трассах.
+	ServiceName string
+	// Output - куда пишет ExporterStdout. По умолчанию os.Stdout.
+	Output io.Writer
+}
+
+// Init настраивает глобальные провайдер трасс и пропагатор.
+//
+// Пропагатор устанавливается всегда, поэтому даже без экспортера контекст
+// входящих запросов передается дальше. Возвращаемая функция отправляет
+// накопленные трассы и останавливает провайдер.
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ExporterStdout:
+		output := cfg.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// End отмечает span ошибкой err, если она не nil, и завершает его.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
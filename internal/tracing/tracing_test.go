@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"Disabled", Config{Exporter: ExporterNone}, false},
+		{"Stdout", Config{Exporter: ExporterStdout, SampleRatio: 1, ServiceName: "test"}, false},
+		{"OTLP", Config{Exporter: ExporterOTLP, Endpoint: "127.0.0.1:1", Insecure: true, SampleRatio: 1}, false},
+		{"Unknown exporter", Config{Exporter: "zipkin"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Init(context.Background(), tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, otel.GetTextMapPropagator())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			// OTLP-коллектора нет, поэтому ошибка отправки при остановке допустима.
+			_ = shutdown(ctx)
+		})
+	}
+}
+
+func TestInit_StdoutExportsSpans(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := Init(context.Background(), Config{
+		Exporter:    ExporterStdout,
+		SampleRatio: 1,
+		ServiceName: "shortener-test",
+		Output:      &out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), `"Name": "test-span"`)
+	assert.Contains(t, out.String(), "shortener-test")
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+		assert.Equal(t, "boom", spans[1].Status().Description)
+	}
+}
@@ -1 +1 @@
-DROP INDEX idx_og_url ON urls;
\ No newline at end of file
+DROP INDEX IF EXISTS idx_og_url;
\ No newline at end of file
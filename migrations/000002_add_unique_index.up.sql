@@ -1,2 +1,2 @@
-CREATE UNIQUE INDEX idx_og_url
+CREATE UNIQUE INDEX IF NOT EXISTS idx_og_url
 ON urls (original_url);
\ No newline at end of file
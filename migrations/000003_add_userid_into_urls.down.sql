@@ -1 +1 @@
-ALTER TABLE urls DROP COLUMN user_id TEXT NOT NULL;
\ No newline at end of file
+ALTER TABLE urls DROP COLUMN IF EXISTS user_id;
\ No newline at end of file
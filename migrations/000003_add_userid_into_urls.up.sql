@@ -1 +1 @@
-ALTER TABLE urls ADD COLUMN user_id TEXT NOT NULL;
\ No newline at end of file
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS user_id TEXT NOT NULL;
\ No newline at end of file
@@ -1 +1 @@
-ALTER TABLE urls DROP COLUMN is_deleted;
\ No newline at end of file
+ALTER TABLE urls DROP COLUMN IF EXISTS is_deleted;
\ No newline at end of file
@@ -1 +1 @@
-ALTER TABLE urls ADD COLUMN is_deleted BOOLEAN DEFAULT false;
\ No newline at end of file
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_deleted BOOLEAN DEFAULT false;
\ No newline at end of file
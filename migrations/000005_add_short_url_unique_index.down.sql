@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_short_url;
\ No newline at end of file
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_short_url
+ON urls (short_url);
\ No newline at end of file
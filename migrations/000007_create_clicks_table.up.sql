@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS clicks (
+	id BIGSERIAL PRIMARY KEY,
+    short_url TEXT NOT NULL,
+    clicked_at TIMESTAMPTZ NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    visitor_id TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_short_url_clicked_at
+ON clicks (short_url, clicked_at);
\ No newline at end of file
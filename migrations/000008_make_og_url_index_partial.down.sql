@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_og_url;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_og_url
+ON urls (original_url);
\ No newline at end of file